@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"taskify/config"
+	"taskify/importers"
+	"taskify/services"
+	"taskify/utils"
+)
+
+// taskify-import imports tasks from the export file of another tracker, as
+// POST /api/v1/tasks/import/{source} does, and prints the import report as JSON.
+// Run it from the repository root so the configuration and workflow files are found:
+//
+//	go run ./cmd/taskify-import -source jira -file export.csv -mapping mapping.json -user admin
+func main() {
+	source := flag.String("source", "", "Tracker the file was exported from: "+strings.Join(importers.Sources(), ", "))
+	file := flag.String("file", "", "Export file to import")
+	mappingFile := flag.String("mapping", "", "JSON file mapping source statuses and users to Taskify ones")
+	user := flag.String("user", "", "Taskify user the import is made as")
+	dryRun := flag.Bool("dry-run", false, "Only validate the items")
+	flag.Parse()
+
+	if *source == "" || *file == "" || *user == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	utils.InitValidator()
+	if err := config.LoadConfig(); err != nil {
+		log.Fatal(err)
+	}
+	config.LoadWorkflow()
+	config.ConnectDatabase()
+	config.EnsureIndexes()
+
+	mapping := &importers.Mapping{}
+	if *mappingFile != "" {
+		f, err := os.Open(*mappingFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mapping, err = importers.LoadMapping(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	items, err := importers.Parse(*source, f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureUserExists(ctx, *user); err != nil {
+		log.Fatal(err)
+	}
+	report, err := services.ImportTrackerItems(ctx, *user, items, mapping, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "%d item(s): %d valid, %d created, %d duplicate(s), %d invalid\n",
+		report.Total, report.Valid, report.Created, report.Duplicates, report.Invalid)
+}
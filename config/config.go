@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
 	"taskify/utils"
@@ -17,6 +18,40 @@ type Config struct {
 	ServerPort    string `validate:"required,numeric,min=1,max=65535"`
 	ServerAddress string `validate:"required,hostname_port|hostname"`
 	Environment   string `validate:"required,oneof=development production test"`
+
+	// Attachment storage
+	StorageDriver          string `validate:"required,oneof=local s3"`
+	StorageLocalPath       string `validate:"required_if=StorageDriver local"`
+	S3Endpoint             string `validate:"required_if=StorageDriver s3"`
+	S3Region               string
+	S3Bucket               string `validate:"required_if=StorageDriver s3"`
+	S3AccessKey            string `validate:"required_if=StorageDriver s3"`
+	S3SecretKey            string `validate:"required_if=StorageDriver s3"`
+	S3UseSSL               bool
+	AttachmentMaxSize      int64    `validate:"required,min=1"`
+	AttachmentAllowedTypes []string `validate:"required,min=1"`
+
+	// Path of the JSON file defining the default task workflow
+	WorkflowFile string `validate:"required"`
+
+	// Number of days trashed tasks are kept before being purged; 0 keeps them forever
+	TrashRetentionDays int `validate:"min=0"`
+
+	// Number of hours responses to requests with an Idempotency-Key are kept for replay
+	IdempotencyKeyTTLHours int `validate:"min=1"`
+
+	// Number of attempts made to deliver a webhook event before giving up on it
+	WebhookMaxAttempts int `validate:"min=1"`
+	// Number of deliveries in a row that may fail before a webhook is disabled
+	WebhookDisableAfterFailures int `validate:"min=1"`
+
+	// Broker relaying WebSocket messages between server instances
+	RealtimeBroker string `validate:"required,oneof=memory mongo"`
+
+	// External sinks the outbox relay publishes domain events to, besides the in-process
+	// event bus: http to post them to OutboxHTTPURL
+	OutboxSinks   []string `validate:"dive,oneof=http"`
+	OutboxHTTPURL string   `validate:"omitempty,url"`
 }
 
 var AppConfig Config
@@ -44,6 +79,31 @@ func LoadConfig() error {
 		DatabaseName:  getEnv("DB_NAME", "taskify"),
 		ServerPort:    getEnv("SERVER_PORT", "3000"),
 		ServerAddress: getEnv("SERVER_ADDRESS", "localhost"),
+
+		StorageDriver:     getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalPath:  getEnv("STORAGE_LOCAL_PATH", "uploads"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+		S3UseSSL:          getEnvBool("S3_USE_SSL", true),
+		AttachmentMaxSize: getEnvInt64("ATTACHMENT_MAX_SIZE", 10<<20),
+		AttachmentAllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{
+			"image/png", "image/jpeg", "image/gif", "application/pdf", "text/plain", "text/csv",
+		}),
+		WorkflowFile:       getEnv("WORKFLOW_FILE", "config/workflow.json"),
+		TrashRetentionDays: int(getEnvInt64("TRASH_RETENTION_DAYS", 30)),
+
+		IdempotencyKeyTTLHours: int(getEnvInt64("IDEMPOTENCY_KEY_TTL_HOURS", 24)),
+
+		WebhookMaxAttempts:          int(getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8)),
+		WebhookDisableAfterFailures: int(getEnvInt64("WEBHOOK_DISABLE_AFTER_FAILURES", 5)),
+
+		RealtimeBroker: getEnv("REALTIME_BROKER", "memory"),
+
+		OutboxSinks:   getEnvList("OUTBOX_SINKS", nil),
+		OutboxHTTPURL: getEnv("OUTBOX_HTTP_URL", ""),
 	}
 
 	// Validate configuration
@@ -64,6 +124,39 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvInt64 gets an integer environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList gets a comma-separated environment variable or returns a default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // ValidateEnvironment validates if the environment is supported
 func ValidateEnvironment(env string) bool {
 	validEnvs := []string{"development", "production", "test"}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -29,4 +30,17 @@ func ConnectDatabase() {
 
 	DB = client.Database(AppConfig.DatabaseName)
 	log.Printf("Connected to MongoDB at %s!", AppConfig.MongoURI)
+
+	// Changes are written in transactions with the events describing them, which are only
+	// available on replica set members and mongos
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := DB.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		log.Fatal("Failed to check for transaction support:", err)
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		log.Fatal("MongoDB must be a replica set or a sharded cluster, as transactions are required")
+	}
 }
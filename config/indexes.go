@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes lists the indexes that must exist on each collection
+var indexes = map[string][]mongo.IndexModel{
+	"tasks": {
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}},
+		{Keys: bson.D{{Key: "recurrence.series_id", Value: 1}}},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "priority_rank", Value: -1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "assignee", Value: 1}, {Key: "calendar_object.name", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "external_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"external_id": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}, {Key: "comment_text", Value: "text"}},
+			Options: options.Index().
+				SetName("task_search").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "description", Value: 5}, {Key: "comment_text", Value: 1}}),
+		},
+	},
+	"feed_tokens": {
+		{Keys: bson.D{{Key: "secret_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	"webhooks": {
+		{Keys: bson.D{{Key: "active", Value: 1}, {Key: "events", Value: 1}}},
+	},
+	"webhook_deliveries": {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}}},
+	},
+	"activities": {
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		// Keeps an event relayed more than once from being recorded twice
+		{
+			Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "action", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"event_id": bson.M{"$exists": true}}),
+		},
+	},
+	"comments": {
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	"idempotency_keys": {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	"attachments": {
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	"outbox": {
+		{Keys: bson.D{{Key: "published_at", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		// Published messages are kept for a day, for troubleshooting
+		{Keys: bson.D{{Key: "published_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60)},
+	},
+	"realtime_messages": {
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(60)},
+	},
+}
+
+// EnsureIndexes creates any missing indexes on the application collections
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for collection, models := range indexes {
+		if _, err := DB.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			log.Fatalf("Failed to create indexes on %s: %v", collection, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+
+	"taskify/realtime"
+)
+
+var Realtime *realtime.Hub
+
+func ConnectRealtime() {
+	var broker realtime.Broker
+	switch AppConfig.RealtimeBroker {
+	case "mongo":
+		broker = realtime.NewMongoBroker(DB.Collection("realtime_messages"))
+	default:
+		broker = realtime.NewMemoryBroker()
+	}
+	Realtime = realtime.NewHub(broker)
+
+	log.Printf("Using %s realtime broker", AppConfig.RealtimeBroker)
+}
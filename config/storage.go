@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"taskify/storage"
+)
+
+var Storage storage.BlobStore
+
+func ConnectStorage() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	switch AppConfig.StorageDriver {
+	case "s3":
+		Storage, err = storage.NewS3Store(ctx, storage.S3Options{
+			Endpoint:  AppConfig.S3Endpoint,
+			Region:    AppConfig.S3Region,
+			Bucket:    AppConfig.S3Bucket,
+			AccessKey: AppConfig.S3AccessKey,
+			SecretKey: AppConfig.S3SecretKey,
+			UseSSL:    AppConfig.S3UseSSL,
+		})
+	default:
+		Storage, err = storage.NewLocalStore(AppConfig.StorageLocalPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Using %s attachment storage", AppConfig.StorageDriver)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/swaggo/swag"
+
+	"taskify/models"
+)
+
+// DefaultWorkflow is used by tasks whose project does not define its own workflow
+var DefaultWorkflow *models.Workflow
+
+// LoadWorkflow loads and validates the default workflow from AppConfig.WorkflowFile
+func LoadWorkflow() {
+	data, err := os.ReadFile(AppConfig.WorkflowFile)
+	if err != nil {
+		log.Fatal("Failed to read workflow file:", err)
+	}
+
+	var workflow models.Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		log.Fatalf("Failed to parse workflow file %s: %v", AppConfig.WorkflowFile, err)
+	}
+	if err := workflow.Validate(); err != nil {
+		log.Fatalf("Invalid workflow in %s: %v", AppConfig.WorkflowFile, err)
+	}
+
+	DefaultWorkflow = &workflow
+	log.Printf("Loaded workflow %q from %s", workflow.Name, AppConfig.WorkflowFile)
+}
+
+// statusSchemas lists the Swagger definitions whose status property follows the workflow
+var statusSchemas = []string{"models.TaskResponse", "models.CreateTaskDTO", "models.UpdateTaskDTO"}
+
+// statusFilterPaths lists the Swagger paths whose GET operation filters tasks by a
+// status query parameter following the workflow
+var statusFilterPaths = []string{"/tasks", "/tasks/export"}
+
+// ApplyWorkflowToSwagger rewrites the status enums of the generated Swagger document so
+// they list the statuses of the default workflow
+func ApplyWorkflowToSwagger(spec *swag.Spec) error {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(spec.ReadDoc()), &doc); err != nil {
+		return err
+	}
+	statuses := DefaultWorkflow.StatusNames()
+
+	definitions, _ := doc["definitions"].(map[string]interface{})
+	for _, name := range statusSchemas {
+		definition, _ := definitions[name].(map[string]interface{})
+		properties, _ := definition["properties"].(map[string]interface{})
+		if status, ok := properties["status"].(map[string]interface{}); ok {
+			status["enum"] = statuses
+		}
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	for _, name := range statusFilterPaths {
+		path, _ := paths[name].(map[string]interface{})
+		operation, _ := path["get"].(map[string]interface{})
+		parameters, _ := operation["parameters"].([]interface{})
+		for _, parameter := range parameters {
+			if parameter, ok := parameter.(map[string]interface{}); ok &&
+				parameter["name"] == "status" && parameter["in"] == "query" {
+				parameter["enum"] = statuses
+			}
+		}
+	}
+
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode swagger document: %w", err)
+	}
+	spec.SwaggerTemplate = string(patched)
+	return nil
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+)
+
+// @Summary Get task activity
+// @Description Get the history of changes made to a task, newest first
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param page query int false "Page number for pagination" default(1)
+// @Param limit query int false "Number of items per page (max 100)" default(10)
+// @Success 200 {object} models.ActivityListResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/activity [get]
+func GetTaskActivity(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	collection := config.DB.Collection("activities")
+	ctx := context.Background()
+	filter := bson.M{"task_id": taskID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	activities := []models.Activity{}
+	if err := cursor.All(ctx, &activities); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  activities,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+	"taskify/storage"
+)
+
+// multipartOverhead is the allowance for multipart boundaries and headers on top of the file size limit
+const multipartOverhead = 1 << 20
+
+// @Summary Upload an attachment
+// @Description Attach a file to a task using a multipart upload
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.AttachmentResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 413 {object} errors.AppError
+// @Failure 415 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/attachments [post]
+func UploadAttachment(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	maxSize := config.AppConfig.AttachmentMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			_ = c.Error(errors.NewPayloadTooLarge(fmt.Sprintf("File exceeds the maximum size of %d bytes", maxSize)))
+			return
+		}
+		_ = c.Error(errors.NewInvalidInput("file is required"))
+		return
+	}
+	if header.Size > maxSize {
+		_ = c.Error(errors.NewPayloadTooLarge(fmt.Sprintf("File exceeds the maximum size of %d bytes", maxSize)))
+		return
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureTaskExists(ctx, taskID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	contentType, err := detectContentType(file)
+	if err != nil {
+		_ = c.Error(errors.NewInternalError(err))
+		return
+	}
+	if !mimetype.EqualsAny(contentType, config.AppConfig.AttachmentAllowedTypes...) {
+		_ = c.Error(errors.NewUnsupportedMediaType(fmt.Sprintf("File type %s is not allowed", contentType)))
+		return
+	}
+
+	attachment := models.NewAttachment(taskID, header.Filename, contentType, header.Size, c.GetString("username"))
+	if err := config.Storage.Put(ctx, attachment.StorageKey, file, header.Size, contentType); err != nil {
+		_ = c.Error(errors.NewInternalError(err))
+		return
+	}
+
+	collection := config.DB.Collection("attachments")
+	if _, err := collection.InsertOne(ctx, attachment); err != nil {
+		_ = config.Storage.Delete(ctx, attachment.StorageKey)
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// @Summary List attachments
+// @Description Get all attachments of a task
+// @Tags Attachments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {array} models.AttachmentResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/attachments [get]
+func GetAttachments(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureTaskExists(ctx, taskID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	collection := config.DB.Collection("attachments")
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"task_id": taskID}, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	attachments := []models.Attachment{}
+	if err := cursor.All(ctx, &attachments); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Download an attachment
+// @Description Download the content of an attachment. Supports HTTP Range requests.
+// @Tags Attachments
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param Range header string false "Byte range to download, e.g. bytes=0-1023"
+// @Success 200 {file} file
+// @Success 206 {file} file "Partial Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 416 "Range Not Satisfiable"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/attachments/{attachmentId} [get]
+func DownloadAttachment(c *gin.Context) {
+	attachment, err := findAttachment(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	blob, err := config.Storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if stderrors.Is(err, storage.ErrBlobNotFound) {
+			_ = c.Error(errors.NewNotFound("Attachment content"))
+			return
+		}
+		_ = c.Error(errors.NewInternalError(err))
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.FileName))
+	http.ServeContent(c.Writer, c.Request, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+// @Summary Delete an attachment
+// @Description Delete an attachment and its stored content
+// @Tags Attachments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/attachments/{attachmentId} [delete]
+func DeleteAttachment(c *gin.Context) {
+	attachment, err := findAttachment(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	collection := config.DB.Collection("attachments")
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": attachment.ID}); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	if err := config.Storage.Delete(ctx, attachment.StorageKey); err != nil {
+		_ = c.Error(errors.NewInternalError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// findAttachment loads the attachment addressed by the id and attachmentId path
+// parameters. Attachments of trashed tasks are not found, like the tasks themselves.
+func findAttachment(c *gin.Context) (*models.Attachment, error) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid task ID format")
+	}
+	attachmentID, err := primitive.ObjectIDFromHex(c.Param("attachmentId"))
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid attachment ID format")
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureTaskExists(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	collection := config.DB.Collection("attachments")
+	var attachment models.Attachment
+	err = collection.FindOne(ctx, bson.M{"_id": attachmentID, "task_id": taskID}).Decode(&attachment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewNotFound("Attachment")
+		}
+		return nil, errors.NewDatabaseError(err)
+	}
+	return &attachment, nil
+}
+
+// detectContentType sniffs the MIME type from the file content and rewinds the file
+func detectContentType(file multipart.File) (string, error) {
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", err
+	}
+	return mtype.String(), nil
+}
@@ -6,11 +6,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"taskify/config"
 	"taskify/errors"
+	"taskify/events"
 	"taskify/models"
+	"taskify/services"
 )
 
 type RegisterRequest struct {
@@ -64,15 +67,21 @@ func Register(c *gin.Context) {
 	}
 
 	// Insert new user
-	result, err := collection.InsertOne(ctx, user)
+	user.ID = primitive.NewObjectID()
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := collection.InsertOne(ctx, user); err != nil {
+			return err
+		}
+		return services.RecordEvent(ctx, events.NewUserRegistered(user))
+	})
 	if err != nil {
-		c.Error(errors.NewDatabaseError(err))
+		c.Error(err)
 		return
 	}
 
 	// Get the inserted user
 	var createdUser models.User
-	err = collection.FindOne(ctx, bson.M{"_id": result.InsertedID}).Decode(&createdUser)
+	err = collection.FindOne(ctx, bson.M{"_id": user.ID}).Decode(&createdUser)
 	if err != nil {
 		c.Error(errors.NewDatabaseError(err))
 		return
@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/middleware"
+	"taskify/models"
+	"taskify/services"
+)
+
+// errBulkAborted stops an atomic bulk request at its first failed operation
+var errBulkAborted = stderrors.New("bulk operation failed")
+
+// @Summary Run bulk task operations
+// @Description Create, update (with a JSON Merge Patch) and delete up to 500 tasks in one request. Every operation is
+// @Description checked against the caller's permissions and reported separately. In atomic mode the operations run in a
+// @Description MongoDB transaction (requires a replica set) and are all rolled back if any fails.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkRequestDTO true "Bulk operations"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.BulkReportResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/bulk [post]
+func BulkTasks(c *gin.Context) {
+	var input models.BulkRequestDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	results := make([]models.BulkResult, len(input.Operations))
+	for i, op := range input.Operations {
+		results[i] = models.BulkResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+
+	report, err := runBulk(input.Atomic, results, func(ctx context.Context, i int) (*models.Task, int, error) {
+		return runBulkOperation(ctx, c, input.Operations[i])
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Update tasks matching a filter
+// @Description Apply the same JSON Merge Patch to every task matching a filter expression (see GET /tasks), e.g.
+// @Description set status=completed where labels = sprint-12. At most 500 tasks may match. Each task is reported separately.
+// @Description In atomic mode the updates run in a MongoDB transaction (requires a replica set) and are all rolled back if any fails.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkUpdateDTO true "Filter and patch"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.BulkReportResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Permission denied"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/bulk/update [post]
+func BulkUpdateTasks(c *gin.Context) {
+	var input models.BulkUpdateDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	if err := authorizeTaskAction(c, "/tasks/:id", http.MethodPatch); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	parsed, err := services.ParseTaskFilter(ctx, input.Filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	cursor, err := config.DB.Collection("tasks").Find(ctx,
+		bson.M{"$and": bson.A{parsed}, "deleted_at": nil},
+		options.Find().
+			SetProjection(bson.M{"_id": 1}).
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(models.MaxBulkOperations+1),
+	)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	var matched []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &matched); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	if len(matched) > models.MaxBulkOperations {
+		_ = c.Error(errors.NewInvalidInput("Filter matches more than " + strconv.Itoa(models.MaxBulkOperations) + " tasks"))
+		return
+	}
+
+	results := make([]models.BulkResult, len(matched))
+	for i, task := range matched {
+		results[i] = models.BulkResult{Index: i, Op: models.BulkUpdate, ID: task.ID.Hex()}
+	}
+
+	report, err := runBulk(input.Atomic, results, func(ctx context.Context, i int) (*models.Task, int, error) {
+		task, err := findTask(ctx, matched[i].ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		return patchTask(ctx, c, task, input.Set)
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// runBulkOperation checks the caller's permission for a single bulk operation and runs it
+func runBulkOperation(ctx context.Context, c *gin.Context, op models.BulkOperationDTO) (*models.Task, int, error) {
+	switch op.Op {
+	case models.BulkCreate:
+		if err := authorizeTaskAction(c, "/tasks", http.MethodPost); err != nil {
+			return nil, 0, err
+		}
+		if op.Task == nil {
+			return nil, 0, errors.NewInvalidInput("task is required to create a task")
+		}
+		task, err := createTask(ctx, c.GetString("username"), *op.Task)
+		return task, http.StatusCreated, err
+
+	case models.BulkUpdate:
+		if err := authorizeTaskAction(c, "/tasks/:id", http.MethodPatch); err != nil {
+			return nil, 0, err
+		}
+		if len(op.Patch) == 0 {
+			return nil, 0, errors.NewInvalidInput("patch is required to update a task")
+		}
+		task, err := findBulkTask(ctx, op)
+		if err != nil {
+			return nil, 0, err
+		}
+		return patchTask(ctx, c, task, op.Patch)
+
+	case models.BulkDelete:
+		if err := authorizeTaskAction(c, "/tasks/:id", http.MethodDelete); err != nil {
+			return nil, 0, err
+		}
+		task, err := findBulkTask(ctx, op)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, http.StatusNoContent, deleteTask(ctx, c.GetString("username"), task)
+	}
+	return nil, 0, errors.NewInvalidInput("Unknown operation " + op.Op)
+}
+
+// findBulkTask loads the task of an update or delete operation and checks its if_match
+func findBulkTask(ctx context.Context, op models.BulkOperationDTO) (*models.Task, error) {
+	id, err := primitive.ObjectIDFromHex(op.ID)
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid task ID format")
+	}
+	task, err := findTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := matchETag(op.IfMatch, task.Version); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// patchTask applies a merge patch to the task and saves it
+func patchTask(ctx context.Context, c *gin.Context, task *models.Task, patch []byte) (*models.Task, int, error) {
+	input, err := patchDocument(mergePatchType, task.Document(), patch)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := replaceTask(ctx, c.GetString("username"), c.GetString("role"), task, *input); err != nil {
+		return nil, 0, err
+	}
+	return task, http.StatusOK, nil
+}
+
+// authorizeTaskAction returns a forbidden error unless the caller may perform the method
+// on the route pattern
+func authorizeTaskAction(c *gin.Context, path, method string) error {
+	allowed, err := middleware.Authorize(c, path, method)
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	if !allowed {
+		return errors.NewForbidden("Permission denied: " + method + " " + path)
+	}
+	return nil
+}
+
+// runBulk runs an operation for each result and records its outcome. In atomic mode the
+// operations run in a transaction that stops and rolls back at the first failure.
+func runBulk(atomic bool, base []models.BulkResult, run func(ctx context.Context, i int) (*models.Task, int, error)) (*models.BulkReport, error) {
+	report := &models.BulkReport{Atomic: atomic}
+
+	execute := func(ctx context.Context) error {
+		report.Results = append([]models.BulkResult(nil), base...)
+		for i := range report.Results {
+			result := &report.Results[i]
+			task, status, err := run(ctx, i)
+			if err != nil {
+				appErr := errors.AsAppError(err)
+				result.Status, result.Error = appErr.StatusCode, appErr.Message
+				if atomic {
+					return errBulkAborted
+				}
+				continue
+			}
+			result.Status, result.Task = status, task
+			if task != nil {
+				result.ID = task.ID.Hex()
+			}
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	if !atomic {
+		_ = execute(ctx)
+		report.Committed = true
+	} else {
+		session, err := config.DB.Client().StartSession()
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+		defer session.EndSession(ctx)
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, execute(sc)
+		})
+		switch {
+		case err == nil:
+			report.Committed = true
+			services.WakeOutboxRelay()
+		case stderrors.Is(err, errBulkAborted):
+			rollBack(report.Results)
+		default:
+			log.Printf("Error: bulk transaction failed: %v", err)
+			return nil, errors.NewDatabaseError(err)
+		}
+	}
+
+	for _, result := range report.Results {
+		if result.Error == "" {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// rollBack marks the operations of an aborted atomic request other than the failed one
+func rollBack(results []models.BulkResult) {
+	failed := false
+	for i := range results {
+		result := &results[i]
+		switch {
+		case result.Error != "":
+			failed = true
+		case failed:
+			result.Status, result.Error = http.StatusFailedDependency, "Not run: an earlier operation failed"
+		default:
+			result.Status, result.Error, result.Task = http.StatusFailedDependency, "Rolled back: a later operation failed", nil
+			if result.Op == models.BulkCreate {
+				result.ID = ""
+			}
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	goical "github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	"github.com/emersion/go-webdav/caldav"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+const (
+	// caldavPrefix is the URL path CalDAV is served under
+	caldavPrefix = "/caldav"
+	// caldavCalendar is the name of the only calendar of each user, holding their tasks
+	caldavCalendar = "tasks"
+)
+
+// todoCategories maps the statuses of to-dos to status categories
+var todoCategories = map[string]string{
+	"NEEDS-ACTION": models.CategoryTodo,
+	"IN-PROCESS":   models.CategoryDoing,
+	"COMPLETED":    models.CategoryDone,
+	"CANCELLED":    models.CategoryDone,
+}
+
+// CalDAV serves the tasks assigned to the authenticated user as a CalDAV (RFC 4791)
+// calendar of to-dos at /caldav/{username}/calendars/tasks/, which clients can discover
+// through /.well-known/caldav. Changes made by clients are validated and authorized like
+// the equivalent task API requests.
+func CalDAV(c *gin.Context) {
+	handler := caldav.Handler{Backend: &caldavBackend{c: c}, Prefix: caldavPrefix}
+	handler.ServeHTTP(c.Writer, c.Request)
+}
+
+// caldavBackend implements caldav.Backend for the user of one request
+type caldavBackend struct {
+	c *gin.Context
+}
+
+func (b *caldavBackend) user() string {
+	return b.c.GetString("username")
+}
+
+func (b *caldavBackend) CurrentUserPrincipal(ctx context.Context) (string, error) {
+	return caldavPrefix + "/" + b.user() + "/", nil
+}
+
+func (b *caldavBackend) CalendarHomeSetPath(ctx context.Context) (string, error) {
+	return caldavPrefix + "/" + b.user() + "/calendars/", nil
+}
+
+func (b *caldavBackend) calendarPath() string {
+	return caldavPrefix + "/" + b.user() + "/calendars/" + caldavCalendar + "/"
+}
+
+func (b *caldavBackend) calendar() caldav.Calendar {
+	return caldav.Calendar{
+		Path:                  b.calendarPath(),
+		Name:                  "Taskify",
+		Description:           "Tasks assigned to " + b.user(),
+		SupportedComponentSet: []string{goical.CompToDo},
+	}
+}
+
+func (b *caldavBackend) CreateCalendar(ctx context.Context, calendar *caldav.Calendar) error {
+	return webdav.NewHTTPError(http.StatusForbidden, stderrors.New("calendars cannot be created"))
+}
+
+func (b *caldavBackend) ListCalendars(ctx context.Context) ([]caldav.Calendar, error) {
+	return []caldav.Calendar{b.calendar()}, nil
+}
+
+func (b *caldavBackend) GetCalendar(ctx context.Context, p string) (*caldav.Calendar, error) {
+	if path.Clean(p) != path.Clean(b.calendarPath()) {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, stderrors.New("calendar not found"))
+	}
+	calendar := b.calendar()
+	return &calendar, nil
+}
+
+func (b *caldavBackend) GetCalendarObject(ctx context.Context, p string, req *caldav.CalendarCompRequest) (*caldav.CalendarObject, error) {
+	if err := authorizeTaskAction(b.c, "/tasks/:id", http.MethodGet); err != nil {
+		return nil, caldavError(err)
+	}
+	task, err := b.findObject(ctx, p)
+	if err != nil {
+		return nil, caldavError(err)
+	}
+	return b.object(ctx, task)
+}
+
+func (b *caldavBackend) ListCalendarObjects(ctx context.Context, p string, req *caldav.CalendarCompRequest) ([]caldav.CalendarObject, error) {
+	if _, err := b.GetCalendar(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := authorizeTaskAction(b.c, "/tasks", http.MethodGet); err != nil {
+		return nil, caldavError(err)
+	}
+
+	cursor, err := config.DB.Collection("tasks").Find(ctx,
+		bson.M{"assignee": b.user(), "deleted_at": nil},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, caldavError(errors.NewDatabaseError(err))
+	}
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, caldavError(errors.NewDatabaseError(err))
+	}
+
+	objects := make([]caldav.CalendarObject, 0, len(tasks))
+	for _, task := range tasks {
+		object, err := b.object(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, *object)
+	}
+	return objects, nil
+}
+
+func (b *caldavBackend) QueryCalendarObjects(ctx context.Context, p string, query *caldav.CalendarQuery) ([]caldav.CalendarObject, error) {
+	objects, err := b.ListCalendarObjects(ctx, p, &query.CompRequest)
+	if err != nil {
+		return nil, err
+	}
+	return caldav.Filter(query, objects)
+}
+
+// PutCalendarObject creates or updates the task stored at the path from a VTODO
+func (b *caldavBackend) PutCalendarObject(ctx context.Context, p string, cal *goical.Calendar, opts *caldav.PutCalendarObjectOptions) (*caldav.CalendarObject, error) {
+	name, ok := b.objectName(p)
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusForbidden, stderrors.New("to-dos can only be stored in the tasks calendar"))
+	}
+	componentType, uid, err := caldav.ValidateCalendarObject(cal)
+	if err != nil {
+		return nil, webdav.NewHTTPError(http.StatusBadRequest, err)
+	}
+	if componentType != goical.CompToDo {
+		return nil, caldav.NewPreconditionError(caldav.PreconditionSupportedCalendarComponent)
+	}
+	todo := cal.Children[0]
+	for _, child := range cal.Children {
+		if child.Name == goical.CompToDo {
+			todo = child
+			break
+		}
+	}
+
+	task, err := b.findObject(ctx, p)
+	if err != nil {
+		var appErr *errors.AppError
+		if !stderrors.As(err, &appErr) || appErr.StatusCode != http.StatusNotFound {
+			return nil, caldavError(err)
+		}
+		task = nil
+	}
+
+	if task == nil {
+		if opts.IfMatch.IsSet() {
+			return nil, webdav.NewHTTPError(http.StatusPreconditionFailed, stderrors.New("to-do does not exist"))
+		}
+		if err := b.checkUID(ctx, uid); err != nil {
+			return nil, err
+		}
+		if task, err = b.createTask(ctx, todo, name, uid); err != nil {
+			return nil, caldavError(err)
+		}
+		return b.object(ctx, task)
+	}
+
+	if opts.IfNoneMatch.IsWildcard() {
+		return nil, webdav.NewHTTPError(http.StatusPreconditionFailed, stderrors.New("to-do already exists"))
+	}
+	if err := matchETag(string(opts.IfMatch), task.Version); err != nil {
+		return nil, caldavError(err)
+	}
+	if err := b.updateTask(ctx, task, todo); err != nil {
+		return nil, caldavError(err)
+	}
+	return b.object(ctx, task)
+}
+
+// DeleteCalendarObject moves the task stored at the path to the trash
+func (b *caldavBackend) DeleteCalendarObject(ctx context.Context, p string) error {
+	if err := authorizeTaskAction(b.c, "/tasks/:id", http.MethodDelete); err != nil {
+		return caldavError(err)
+	}
+	task, err := b.findObject(ctx, p)
+	if err != nil {
+		return caldavError(err)
+	}
+	if err := checkIfMatch(b.c, task.Version); err != nil {
+		return caldavError(err)
+	}
+	return caldavError(deleteTask(ctx, b.user(), task))
+}
+
+// createTask creates a task assigned to the user from a new to-do
+func (b *caldavBackend) createTask(ctx context.Context, todo *goical.Component, name, uid string) (*models.Task, error) {
+	if err := authorizeTaskAction(b.c, "/tasks", http.MethodPost); err != nil {
+		return nil, err
+	}
+
+	workflow, err := services.WorkflowFor(ctx, "")
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	input := models.CreateTaskDTO{Assignee: b.user()}
+	if input.Title, err = todo.Props.Text(goical.PropSummary); err != nil {
+		return nil, errors.NewInvalidInput("Invalid SUMMARY: " + err.Error())
+	}
+	if input.Description, err = todo.Props.Text(goical.PropDescription); err != nil {
+		return nil, errors.NewInvalidInput("Invalid DESCRIPTION: " + err.Error())
+	}
+	if input.Priority, err = todoPriority(todo); err != nil {
+		return nil, err
+	}
+	if input.DueAt, err = todoDue(todo); err != nil {
+		return nil, err
+	}
+	if input.Labels, err = todoLabels(todo); err != nil {
+		return nil, err
+	}
+	if input.Status, err = todoStatus(todo, workflow, ""); err != nil {
+		return nil, err
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, errors.NewInvalidInput(err.Error())
+	}
+
+	task, err := services.NewTask(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	task.CalendarObject = &models.CalendarObject{Name: name, UID: uid}
+	if err := insertTask(ctx, b.user(), task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// updateTask applies the properties of a to-do to its task. Properties the to-do does
+// not support, such as the project, are kept.
+func (b *caldavBackend) updateTask(ctx context.Context, task *models.Task, todo *goical.Component) error {
+	if err := authorizeTaskAction(b.c, "/tasks/:id", http.MethodPut); err != nil {
+		return err
+	}
+
+	workflow, err := services.WorkflowFor(ctx, task.Project)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	input := task.Document()
+	if input.Title, err = todo.Props.Text(goical.PropSummary); err != nil {
+		return errors.NewInvalidInput("Invalid SUMMARY: " + err.Error())
+	}
+	if input.Description, err = todo.Props.Text(goical.PropDescription); err != nil {
+		return errors.NewInvalidInput("Invalid DESCRIPTION: " + err.Error())
+	}
+	priority, err := todoPriority(todo)
+	if err != nil {
+		return err
+	}
+	if priority != "" {
+		input.Priority = priority
+	}
+	if input.DueAt, err = todoDue(todo); err != nil {
+		return err
+	}
+	if input.Labels, err = todoLabels(todo); err != nil {
+		return err
+	}
+	if input.Status, err = todoStatus(todo, workflow, task.Status); err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return errors.NewInvalidInput(err.Error())
+	}
+
+	return replaceTask(ctx, b.user(), b.c.GetString("role"), task, input)
+}
+
+// checkUID fails with the no-uid-conflict precondition if another to-do in the calendar
+// already has the UID
+func (b *caldavBackend) checkUID(ctx context.Context, uid string) error {
+	filter := bson.M{"assignee": b.user(), "deleted_at": nil, "calendar_object.uid": uid}
+	if id, err := primitive.ObjectIDFromHex(strings.TrimSuffix(uid, "@taskify")); err == nil {
+		filter = bson.M{"assignee": b.user(), "deleted_at": nil, "$or": bson.A{
+			bson.M{"calendar_object.uid": uid},
+			bson.M{"_id": id, "calendar_object": nil},
+		}}
+	}
+	count, err := config.DB.Collection("tasks").CountDocuments(ctx, filter)
+	if err != nil {
+		return caldavError(errors.NewDatabaseError(err))
+	}
+	if count > 0 {
+		return caldav.NewPreconditionError(caldav.PreconditionNoUIDConflict)
+	}
+	return nil
+}
+
+// objectName returns the resource name of a to-do path in the user's calendar, without
+// the .ics extension
+func (b *caldavBackend) objectName(p string) (string, bool) {
+	dir, file := path.Split(p)
+	if path.Clean(dir) != path.Clean(b.calendarPath()) || !strings.HasSuffix(file, ".ics") {
+		return "", false
+	}
+	name := strings.TrimSuffix(file, ".ics")
+	return name, name != ""
+}
+
+// findObject loads the task stored at a to-do path in the user's calendar
+func (b *caldavBackend) findObject(ctx context.Context, p string) (*models.Task, error) {
+	name, ok := b.objectName(p)
+	if !ok {
+		return nil, errors.NewNotFound("Task")
+	}
+	filter := bson.M{"assignee": b.user(), "deleted_at": nil, "calendar_object.name": name}
+	if id, err := primitive.ObjectIDFromHex(name); err == nil {
+		filter = bson.M{"assignee": b.user(), "deleted_at": nil, "$or": bson.A{
+			bson.M{"calendar_object.name": name},
+			bson.M{"_id": id, "calendar_object": nil},
+		}}
+	}
+
+	var task models.Task
+	err := config.DB.Collection("tasks").FindOne(ctx, filter).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.NewNotFound("Task")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	return &task, nil
+}
+
+// object renders a task as a calendar object
+func (b *caldavBackend) object(ctx context.Context, task *models.Task) (*caldav.CalendarObject, error) {
+	baseURL := requestBaseURL(b.c)
+	var body bytes.Buffer
+	err := services.WriteCalendar(ctx, &body, []*models.Task{task}, services.CalendarOptions{
+		Component: services.CalendarTodos,
+		TaskURL: func(task *models.Task) string {
+			return baseURL + "/api/v1/tasks/" + task.ID.Hex()
+		},
+	})
+	if err != nil {
+		return nil, caldavError(err)
+	}
+	cal, err := goical.NewDecoder(&body).Decode()
+	if err != nil {
+		return nil, caldavError(errors.NewInternalError(err))
+	}
+
+	return &caldav.CalendarObject{
+		Path:    b.calendarPath() + task.CalendarName() + ".ics",
+		ModTime: task.UpdatedAt,
+		ETag:    strconv.FormatInt(task.Version, 10),
+		Data:    cal,
+	}, nil
+}
+
+// todoPriority maps the PRIORITY of a to-do to a task priority, or returns an empty
+// string if it is undefined
+func todoPriority(todo *goical.Component) (string, error) {
+	prop := todo.Props.Get(goical.PropPriority)
+	if prop == nil {
+		return "", nil
+	}
+	priority, err := prop.Int()
+	if err != nil {
+		return "", errors.NewInvalidInput("Invalid PRIORITY: " + err.Error())
+	}
+	switch {
+	case priority <= 0:
+		return "", nil
+	case priority <= 2:
+		return models.PriorityUrgent, nil
+	case priority <= 4:
+		return models.PriorityHigh, nil
+	case priority == 5:
+		return models.PriorityMedium, nil
+	default:
+		return models.PriorityLow, nil
+	}
+}
+
+// todoDue returns the DUE date of a to-do. All-day dates become midnight UTC, matching
+// how they are written.
+func todoDue(todo *goical.Component) (*time.Time, error) {
+	prop := todo.Props.Get(goical.PropDue)
+	if prop == nil {
+		return nil, nil
+	}
+	due, err := prop.DateTime(time.UTC)
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid DUE: " + err.Error())
+	}
+	return &due, nil
+}
+
+// todoLabels returns the CATEGORIES of a to-do as labels
+func todoLabels(todo *goical.Component) ([]string, error) {
+	var labels []string
+	for _, prop := range todo.Props.Values(goical.PropCategories) {
+		categories, err := prop.TextList()
+		if err != nil {
+			return nil, errors.NewInvalidInput("Invalid CATEGORIES: " + err.Error())
+		}
+		for _, category := range categories {
+			if category = strings.TrimSpace(category); category != "" && !slices.Contains(labels, category) {
+				labels = append(labels, category)
+			}
+		}
+	}
+	return labels, nil
+}
+
+// todoStatus maps the STATUS of a to-do to a status of the workflow. The current status
+// is kept while it is in the same category, as clients only know the categories.
+func todoStatus(todo *goical.Component, workflow *models.Workflow, current string) (string, error) {
+	var category string
+	if prop := todo.Props.Get(goical.PropStatus); prop != nil {
+		var ok bool
+		if category, ok = todoCategories[strings.ToUpper(prop.Value)]; !ok {
+			return "", errors.NewInvalidInput("Invalid STATUS: " + prop.Value)
+		}
+	} else if todo.Props.Get(goical.PropCompleted) != nil {
+		category = models.CategoryDone
+	} else if current != "" {
+		return current, nil
+	} else {
+		category = models.CategoryTodo
+	}
+
+	if current != "" && workflow.Category(current) == category {
+		return current, nil
+	}
+	status := workflow.StatusIn(category)
+	if status == "" {
+		return "", errors.NewInvalidInput("The workflow has no " + category + " status")
+	}
+	return status, nil
+}
+
+// caldavError converts application errors into WebDAV errors with the same status
+func caldavError(err error) error {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return webdav.NewHTTPError(appErr.StatusCode, err)
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/events"
+	"taskify/models"
+	"taskify/services"
+)
+
+// @Summary Comment on a task
+// @Description Add a comment to a task
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param comment body models.CreateCommentDTO true "Comment object"
+// @Success 201 {object} models.CommentResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/comments [post]
+func CreateComment(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	var input models.CreateCommentDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureTaskExists(ctx, taskID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	actor := c.GetString("username")
+	comment := models.NewComment(taskID, actor, input.Body)
+
+	comment.ID = primitive.NewObjectID()
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := config.DB.Collection("comments").InsertOne(ctx, comment); err != nil {
+			return err
+		}
+
+		// Make the comment searchable through the task's text index. The version changes
+		// too, so that a concurrent replacement of the task does not drop the comment.
+		var task models.Task
+		err := config.DB.Collection("tasks").FindOneAndUpdate(ctx,
+			bson.M{"_id": taskID},
+			bson.M{"$push": bson.M{"comment_text": comment.Body}, "$inc": bson.M{"version": 1}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&task)
+		if err != nil {
+			return err
+		}
+		return services.RecordEvent(ctx, events.NewTaskCommented(&task, comment))
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// @Summary List task comments
+// @Description Get all comments on a task, oldest first
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {array} models.CommentResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/comments [get]
+func GetComments(c *gin.Context) {
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	ctx := context.Background()
+	if err := services.EnsureTaskExists(ctx, taskID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	collection := config.DB.Collection("comments")
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"task_id": taskID}, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+)
+
+// @Summary List custom fields
+// @Description Get all custom field definitions, including archived ones
+// @Tags Custom Fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.CustomField
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /custom-fields [get]
+func GetCustomFields(c *gin.Context) {
+	collection := config.DB.Collection("custom_fields")
+	ctx := context.Background()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	fields := []models.CustomField{}
+	if err := cursor.All(ctx, &fields); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+// @Summary Create a custom field
+// @Description Define a new custom field that tasks can carry. Admin only.
+// @Tags Custom Fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param field body models.CustomFieldDTO true "Custom field definition"
+// @Success 201 {object} models.CustomField
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 500 {object} errors.AppError
+// @Router /custom-fields [post]
+func CreateCustomField(c *gin.Context) {
+	var input models.CustomFieldDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	field, err := models.NewCustomField(input)
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	collection := config.DB.Collection("custom_fields")
+	if _, err := collection.InsertOne(context.Background(), field); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			_ = c.Error(errors.NewInvalidInput("Custom field " + field.Key + " already exists"))
+			return
+		}
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// @Summary Update a custom field
+// @Description Change the name and validation rules of a custom field. The key and type cannot be changed, and values already stored on tasks are kept. Admin only.
+// @Tags Custom Fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "Custom field key"
+// @Param field body models.UpdateCustomFieldDTO true "Custom field changes"
+// @Success 200 {object} models.CustomField
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /custom-fields/{key} [put]
+func UpdateCustomField(c *gin.Context) {
+	var input models.UpdateCustomFieldDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	collection := config.DB.Collection("custom_fields")
+	ctx := context.Background()
+
+	var field models.CustomField
+	err := collection.FindOne(ctx, bson.M{"_id": c.Param("key")}).Decode(&field)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			_ = c.Error(errors.NewNotFound("Custom field"))
+			return
+		}
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	if err := field.Update(input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": field.Key}, field); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// @Summary Archive a custom field
+// @Description Archive a custom field. Archived fields can no longer be set, but values already stored on tasks are kept. Admin only.
+// @Tags Custom Fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "Custom field key"
+// @Success 204 "No Content"
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /custom-fields/{key} [delete]
+func ArchiveCustomField(c *gin.Context) {
+	collection := config.DB.Collection("custom_fields")
+	result, err := collection.UpdateOne(context.Background(),
+		bson.M{"_id": c.Param("key")},
+		bson.M{"$set": bson.M{"archived": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		_ = c.Error(errors.NewNotFound("Custom field"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
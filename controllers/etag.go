@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/errors"
+)
+
+// taskETag returns the entity tag of a version of a task
+func taskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// checkIfMatch returns a precondition failed error if the request has an If-Match header
+// that does not match the given version. Weak entity tags never match.
+func checkIfMatch(c *gin.Context, version int64) error {
+	return matchETag(c.GetHeader("If-Match"), version)
+}
+
+// matchETag checks an If-Match value against the given version; an empty value matches
+func matchETag(header string, version int64) error {
+	if header == "" {
+		return nil
+	}
+	current := taskETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return errors.NewPreconditionFailed("Task has been modified; current ETag is " + current)
+}
+
+// versionFilter matches the given task version. Tasks created before versioning have no
+// version and are treated as version 0.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
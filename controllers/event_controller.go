@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+const (
+	// streamHeartbeat is how often an idle event stream sends a comment, keeping proxies
+	// from closing the connection
+	streamHeartbeat = 15 * time.Second
+	// streamRetry is the reconnection delay suggested to clients, in milliseconds
+	streamRetry = 3000
+)
+
+// streamEventRoutes maps stream events to the API route a caller must be allowed to read
+// to receive them
+var streamEventRoutes = map[string]string{
+	models.EventTaskCreated:   "/tasks/:id",
+	models.EventTaskUpdated:   "/tasks/:id",
+	models.EventTaskDeleted:   "/tasks/:id",
+	models.EventTaskRestored:  "/tasks/:id",
+	models.EventTaskPurged:    "/tasks/:id",
+	models.EventTaskCommented: "/tasks/:id/comments",
+}
+
+// @Summary Stream task events
+// @Description Stream task changes as Server-Sent Events, replacing polling. Each event is named after the change, e.g.
+// @Description task.updated, and carries the same JSON as webhook payloads. Only events the caller's role may read are sent.
+// @Description Clients reconnecting with Last-Event-ID receive the events they missed while those are still buffered; if
+// @Description they are not, a reset event is sent first and the client should reload its tasks. Idle streams send a
+// @Description heartbeat comment every 15 seconds.
+// @Tags Events
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param events query string false "Comma-separated events to receive, e.g. task.created,task.updated; all by default"
+// @Param project query string false "Only events of tasks in this project"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume after reconnecting"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /events [get]
+func StreamEvents(c *gin.Context) {
+	var lastID *uint64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput("Invalid Last-Event-ID"))
+			return
+		}
+		lastID = &id
+	}
+
+	var requested []string
+	for _, event := range strings.Split(c.Query("events"), ",") {
+		if event = strings.TrimSpace(event); event == "" {
+			continue
+		}
+		if _, ok := streamEventRoutes[event]; !ok {
+			_ = c.Error(errors.NewInvalidInput("Unknown event " + event))
+			return
+		}
+		requested = append(requested, event)
+	}
+
+	allowed := map[string]bool{}
+	for event, route := range streamEventRoutes {
+		if len(requested) > 0 && !slices.Contains(requested, event) {
+			continue
+		}
+		if err := authorizeTaskAction(c, route, http.MethodGet); err == nil {
+			allowed[event] = true
+		}
+	}
+	project := c.Query("project")
+
+	backlog, resumed, events, unsubscribe := services.TaskStream.Subscribe(lastID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event services.StreamEvent) {
+		if !allowed[event.Event] || (project != "" && event.Project != project) {
+			return
+		}
+		c.Render(-1, sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: event.Event, Data: string(event.Data)})
+	}
+
+	c.Render(-1, sse.Event{Event: "ready", Retry: streamRetry, Data: "{}"})
+	if !resumed {
+		c.Render(-1, sse.Event{Event: "reset", Data: "{}"})
+	}
+	for _, event := range backlog {
+		send(event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind, or the server is shutting down
+				return
+			}
+			send(event)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
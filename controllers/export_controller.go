@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+const (
+	// exportBatchSize is the number of tasks fetched from the database at a time
+	exportBatchSize = 500
+	// exportFlushEvery is the number of rows written between flushes to the client
+	exportFlushEvery = 100
+)
+
+// exportContentTypes maps the supported export formats to their media types
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"json":   "application/json; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// @Summary Export tasks
+// @Description Stream all tasks matching the same filters as GET /tasks as a CSV, JSON or NDJSON file download.
+// @Description Columns default to every task field followed by every active custom field.
+// @Tags Tasks
+// @Produce text/csv
+// @Produce json
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "Export format" Enums(csv, json, ndjson) default(csv)
+// @Param fields query string false "Comma-separated columns, e.g. id,title,status,due_at,cf.cost_center"
+// @Param q query string false "Full-text search over title, description and comments"
+// @Param status query string false "Filter by status, one of the statuses of the workflow"
+// @Param filter query string false "Filter expression, as for GET /tasks"
+// @Param sort query string false "Comma-separated sort fields, as for GET /tasks"
+// @Param cf.key query string false "Filter by custom field value, e.g. cf.story_points=3"
+// @Success 200 {file} file "Task export"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/export [get]
+func ExportTasks(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		_ = c.Error(errors.NewInvalidInput("format must be one of csv, json, ndjson"))
+		return
+	}
+
+	// Stop reading from the database when the client goes away
+	ctx := c.Request.Context()
+	filter, query, err := taskListFilter(ctx, c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	_, sortKeys, err := taskListSort(ctx, c, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	columns, projection, err := services.ParseExportColumns(ctx, c.Query("fields"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	findOptions := options.Find().SetBatchSize(exportBatchSize)
+	if sortKeys == nil {
+		projection["score"] = bson.M{"$meta": "textScore"}
+		sortKeys = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}
+	}
+	findOptions.SetSort(sortKeys).SetProjection(projection)
+
+	cursor, err := config.DB.Collection("tasks").Find(ctx, filter, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	// Large exports may take longer than the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("tasks-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	exporter := newTaskExporter(format, c.Writer, columns)
+	if err := exporter.begin(); err != nil {
+		return
+	}
+	for rows := 1; cursor.Next(ctx); rows++ {
+		var task models.Task
+		if err := cursor.Decode(&task); err != nil {
+			log.Printf("Error: task export stopped: %v", err)
+			return
+		}
+		if err := exporter.write(&task); err != nil {
+			return
+		}
+		if rows%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		// The status has been sent; an incomplete file is all that can be reported
+		log.Printf("Error: task export stopped: %v", err)
+		return
+	}
+	_ = exporter.end()
+	c.Writer.Flush()
+}
+
+// taskExporter writes tasks in an export format
+type taskExporter interface {
+	begin() error
+	write(task *models.Task) error
+	end() error
+}
+
+func newTaskExporter(format string, w io.Writer, columns []string) taskExporter {
+	switch format {
+	case "json":
+		return &jsonExporter{w: w, columns: columns}
+	case "ndjson":
+		return &ndjsonExporter{encoder: json.NewEncoder(w), columns: columns}
+	}
+	return &csvExporter{w: csv.NewWriter(w), columns: columns}
+}
+
+// exportRecord returns the columns of a task keyed by column name
+func exportRecord(task *models.Task, columns []string) map[string]interface{} {
+	record := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		record[column] = services.ExportValue(task, column)
+	}
+	return record
+}
+
+type csvExporter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (e *csvExporter) begin() error {
+	return e.w.Write(e.columns)
+}
+
+func (e *csvExporter) write(task *models.Task) error {
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = csvCell(services.ExportValue(task, column))
+	}
+	if err := e.w.Write(row); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvFormulaPrefixes are the characters that make spreadsheets evaluate a cell
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvLabelSeparator separates the labels of a task in CSV exports and imports
+const csvLabelSeparator = ","
+
+// csvCell formats a value for a CSV cell. Text that spreadsheets would evaluate as a
+// formula is prefixed with a quote, as is text that already looks guarded so that
+// csvCellText restores it.
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if csvNeedsQuote(v) {
+			return "'" + v
+		}
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339)
+	case []string:
+		return csvCell(strings.Join(v, csvLabelSeparator+" "))
+	case float64, int, int32, int64, bool:
+		return fmt.Sprint(v)
+	}
+	data, _ := json.Marshal(value)
+	return csvCell(string(data))
+}
+
+// csvNeedsQuote reports whether csvCell prefixes text with a quote
+func csvNeedsQuote(text string) bool {
+	return text != "" && (strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) || csvCellText(text) != text)
+}
+
+// csvCellText returns the text of a CSV cell written by csvCell, without the quote
+// prefixed to formulas
+func csvCellText(cell string) string {
+	if strings.HasPrefix(cell, "'") && csvNeedsQuote(cell[1:]) {
+		return cell[1:]
+	}
+	return cell
+}
+
+type jsonExporter struct {
+	w       io.Writer
+	columns []string
+	written bool
+}
+
+func (e *jsonExporter) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExporter) write(task *models.Task) error {
+	data, err := json.Marshal(exportRecord(task, e.columns))
+	if err != nil {
+		return err
+	}
+	if e.written {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.written = true
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExporter) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type ndjsonExporter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (e *ndjsonExporter) begin() error { return nil }
+
+func (e *ndjsonExporter) write(task *models.Task) error {
+	return e.encoder.Encode(exportRecord(task, e.columns))
+}
+
+func (e *ndjsonExporter) end() error { return nil }
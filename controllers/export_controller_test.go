@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"", ""},
+		{"Fix login", "Fix login"},
+		{"=SUM(A1:A9)", "'=SUM(A1:A9)"},
+		{"+1 555 0100", "'+1 555 0100"},
+		{"-5", "'-5"},
+		{"@mention", "'@mention"},
+		{"\tindented", "'\tindented"},
+		{"'quoted", "'quoted"},
+		{"'=SUM(A1:A9)", "''=SUM(A1:A9)"},
+		{"''=x", "'''=x"},
+		{[]string{"bug", "ui"}, "bug, ui"},
+		{[]string{"=cmd", "ui"}, "'=cmd, ui"},
+		{float64(3), "3"},
+		{true, "true"},
+		{time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600)), "2024-03-01T08:30:00Z"},
+	}
+	for _, tt := range tests {
+		if got := csvCell(tt.value); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCSVCellText(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"'=SUM(A1:A9)", "=SUM(A1:A9)"},
+		{"''=x", "'=x"},
+		{"'quoted", "'quoted"},
+		{"'", "'"},
+	}
+	for _, tt := range tests {
+		if got := csvCellText(tt.cell); got != tt.want {
+			t.Errorf("csvCellText(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestCSVImportRoundTrip(t *testing.T) {
+	tests := []struct {
+		title  string
+		labels []string
+	}{
+		{"Fix login", []string{"bug", "ui"}},
+		{"=HYPERLINK(\"http://example.com\")", []string{"=cmd", "-1"}},
+		{"'=already guarded", []string{"'quoted", "@team"}},
+		{"''=twice", nil},
+		{"'", []string{}},
+		{"-", []string{"+"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write([]string{"title", "labels"})
+		_ = w.Write([]string{csvCell(tt.title), csvCell(tt.labels)})
+		w.Flush()
+
+		records, err := readCSVImport(&buf, map[string]string{"title": "title"})
+		if err != nil {
+			t.Fatalf("readCSVImport(%q) error = %v", buf.String(), err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("readCSVImport(%q) read %d records, want 1", buf.String(), len(records))
+		}
+		if got := records[0].values["title"]; got != tt.title {
+			t.Errorf("title %q round-tripped as %q", tt.title, got)
+		}
+		labels, err := importLabels(records[0].values["labels"])
+		if err != nil {
+			t.Fatalf("importLabels(%q) error = %v", records[0].values["labels"], err)
+		}
+		if len(labels) != 0 || len(tt.labels) != 0 {
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("labels %q round-tripped as %q", tt.labels, labels)
+			}
+		}
+	}
+}
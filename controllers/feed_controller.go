@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+// feedComponents maps the type query parameter of feeds to calendar components
+var feedComponents = map[string]string{
+	"vevent": services.CalendarEvents,
+	"vtodo":  services.CalendarTodos,
+}
+
+// @Summary Create a calendar feed
+// @Description Create a secret URL serving the caller's tasks with due dates as an iCalendar (RFC 5545) feed, to subscribe to
+// @Description from Outlook, Google Calendar and other clients. The URL is only returned once; anyone who has it can read
+// @Description the feed until the feed is deleted. See GET /calendar/{token}/tasks.ics for the filters the URL accepts.
+// @Tags Feeds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param feed body models.CreateFeedTokenDTO true "Feed name"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 201 {object} models.CreatedFeedTokenResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /feeds [post]
+func CreateFeedToken(c *gin.Context) {
+	var input models.CreateFeedTokenDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	token, secret, err := models.NewFeedToken(c.GetString("username"), input.Name)
+	if err != nil {
+		_ = c.Error(errors.NewInternalError(err))
+		return
+	}
+	result, err := config.DB.Collection("feed_tokens").InsertOne(context.Background(), token)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	token.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         token.ID,
+		"name":       token.Name,
+		"created_at": token.CreatedAt,
+		"url":        requestBaseURL(c) + "/api/v1/calendar/" + secret + "/tasks.ics",
+	})
+}
+
+// @Summary List calendar feeds
+// @Description Get the caller's calendar feeds. Feed URLs are not included.
+// @Tags Feeds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.FeedTokenResponse
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /feeds [get]
+func GetFeedTokens(c *gin.Context) {
+	ctx := context.Background()
+	cursor, err := config.DB.Collection("feed_tokens").Find(ctx,
+		bson.M{"user": c.GetString("username")},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	tokens := []models.FeedToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Delete a calendar feed
+// @Description Revoke one of the caller's calendar feeds; its URL stops working immediately
+// @Tags Feeds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Feed ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /feeds/{id} [delete]
+func DeleteFeedToken(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid feed ID format"))
+		return
+	}
+
+	result, err := config.DB.Collection("feed_tokens").DeleteOne(context.Background(),
+		bson.M{"_id": id, "user": c.GetString("username")})
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	if result.DeletedCount == 0 {
+		_ = c.Error(errors.NewNotFound("Feed"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a calendar feed
+// @Description Serve the feed owner's tasks with due dates as an iCalendar feed. Tasks appear as events on their due
+// @Description date, or as to-dos with type=vtodo, with their status, priority and a link back. By default the feed only
+// @Description has the tasks assigned to its owner; assignee=all includes everyone's tasks. The owner's role must still
+// @Description allow listing tasks. Feeds are served with an ETag: polling with If-None-Match returns 304 while nothing
+// @Description has changed. Public endpoint authenticated by the secret token in the URL.
+// @Tags Feeds
+// @Produce text/calendar
+// @Param token path string true "Secret feed token"
+// @Param type query string false "Calendar component tasks are rendered as" Enums(vevent, vtodo) default(vevent)
+// @Param project query string false "Only tasks of this project"
+// @Param assignee query string false "Only tasks assigned to this user; me for the feed's owner, all for every user" default(me)
+// @Param labels query string false "Comma-separated labels tasks must all have"
+// @Param If-None-Match header string false "ETag of the feed the client has"
+// @Success 200 {file} file "iCalendar feed"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} errors.AppError
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /calendar/{token}/tasks.ics [get]
+func GetTaskFeed(c *gin.Context) {
+	componentName := c.DefaultQuery("type", "vevent")
+	component, ok := feedComponents[componentName]
+	if !ok {
+		_ = c.Error(errors.NewInvalidInput("type must be one of vevent, vtodo"))
+		return
+	}
+
+	ctx := context.Background()
+	var token models.FeedToken
+	err := config.DB.Collection("feed_tokens").FindOne(ctx,
+		bson.M{"secret_hash": models.HashFeedSecret(c.Param("token"))}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		_ = c.Error(errors.NewNotFound("Feed"))
+		return
+	}
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	// Feeds of deleted users stop working, and feeds only show what the owner's
+	// current role may list
+	var owner models.User
+	err = config.DB.Collection("users").FindOne(ctx, bson.M{"username": token.User}).Decode(&owner)
+	if err == mongo.ErrNoDocuments {
+		_ = c.Error(errors.NewNotFound("Feed"))
+		return
+	}
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.Set("role", owner.Role)
+	if err := authorizeTaskAction(c, "/tasks", http.MethodGet); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter := bson.M{"due_at": bson.M{"$ne": nil}}
+	if project := c.Query("project"); project != "" {
+		filter["project"] = project
+	}
+	switch assignee := c.DefaultQuery("assignee", "me"); assignee {
+	case "all":
+	case "me":
+		filter["assignee"] = token.User
+	default:
+		filter["assignee"] = assignee
+	}
+	var labels []string
+	for _, label := range strings.Split(c.Query("labels"), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) > 0 {
+		filter["labels"] = bson.M{"$all": labels}
+	}
+
+	// The ETag is derived from the matching tasks, including those in the trash, so that
+	// polling clients with a current copy are answered without loading and rendering them
+	baseURL := requestBaseURL(c)
+	etag, err := feedETag(ctx, filter, componentName, token.Name, baseURL)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+	if noneMatch(c.GetHeader("If-None-Match"), etag) {
+		touchFeedToken(ctx, &token)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	filter["deleted_at"] = nil
+	cursor, err := config.DB.Collection("tasks").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	var body bytes.Buffer
+	err = services.WriteCalendar(ctx, &body, tasks, services.CalendarOptions{
+		Name:      "Taskify: " + token.Name,
+		Method:    "PUBLISH",
+		Component: component,
+		Details:   true,
+		TaskURL: func(task *models.Task) string {
+			return baseURL + "/api/v1/tasks/" + task.ID.Hex()
+		},
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	touchFeedToken(ctx, &token)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+// feedETag returns the ETag of a feed from the number, latest change and total version
+// of the tasks matching its filter, counting trashed tasks separately so that trashing,
+// restoring and purging tasks change it too
+func feedETag(ctx context.Context, filter bson.M, parts ...string) (string, error) {
+	cursor, err := config.DB.Collection("tasks").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        nil,
+			"count":      bson.M{"$sum": 1},
+			"trashed":    bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$deleted_at", false}}, 1, 0}}},
+			"versions":   bson.M{"$sum": "$version"},
+			"updated_at": bson.M{"$max": "$updated_at"},
+			"deleted_at": bson.M{"$max": "$deleted_at"},
+		}}},
+	})
+	if err != nil {
+		return "", errors.NewDatabaseError(err)
+	}
+	var stats []bson.M
+	if err := cursor.All(ctx, &stats); err != nil {
+		return "", errors.NewDatabaseError(err)
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%v\n", filter)
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%s\n", part)
+	}
+	for _, stat := range stats {
+		fmt.Fprintf(hash, "%v %v %v %v %v\n",
+			stat["count"], stat["trashed"], stat["versions"], stat["updated_at"], stat["deleted_at"])
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, nil
+}
+
+// feedTouchInterval is how often polling a feed updates its last use, so that clients
+// polling every few minutes do not cause a write each time
+const feedTouchInterval = time.Hour
+
+// touchFeedToken records that the feed was used, at most once per feedTouchInterval
+func touchFeedToken(ctx context.Context, token *models.FeedToken) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < feedTouchInterval {
+		return
+	}
+	_, _ = config.DB.Collection("feed_tokens").UpdateOne(ctx,
+		bson.M{"_id": token.ID},
+		bson.M{"$set": bson.M{"last_used_at": now}})
+}
+
+// noneMatch reports whether an If-None-Match header matches the ETag, in which case
+// the client's copy is current
+func noneMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// requestBaseURL returns the scheme and host the request was made to, as seen by the
+// client when behind a TLS-terminating proxy
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
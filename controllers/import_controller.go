@@ -0,0 +1,456 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"taskify/errors"
+	"taskify/importers"
+	"taskify/models"
+	"taskify/services"
+)
+
+const (
+	// maxImportSize is the largest import file accepted, in bytes
+	maxImportSize = 10 << 20
+)
+
+// importRecord is one row of an import file keyed by source column, with its row number
+type importRecord struct {
+	row    int
+	values map[string]interface{}
+}
+
+// @Summary Import tasks
+// @Description Create tasks from a CSV file with a header row or a JSON array of objects. The mapping assigns source
+// @Description columns to task fields (external_id, title, description, status, priority, assignee, project, parent_id,
+// @Description due_at, labels and cf.<key> for custom fields); by default each field is read from the column of the
+// @Description same name, as written by GET /tasks/export. Labels may be given as a comma-separated list. Cells that
+// @Description start with a quote followed by = + - or @, as exports write formulas, are read without the quote.
+// @Description Every row is validated and reported separately. A dry run only validates; otherwise the valid rows are
+// @Description inserted in batches. Rows whose external ID already exists, in the database or earlier in the file,
+// @Description are skipped as duplicates.
+// @Tags Tasks
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or JSON file of at most 5000 rows"
+// @Param format formData string false "File format; defaults to json for .json files and csv otherwise" Enums(csv, json)
+// @Param mapping formData string false "JSON object mapping task fields to source columns, e.g. {\"title\":\"Summary\",\"external_id\":\"Key\"}"
+// @Param dry_run formData bool false "Only validate the rows"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} errors.AppError
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/import [post]
+func ImportTasks(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			_ = c.Error(errors.NewPayloadTooLarge(fmt.Sprintf("File exceeds the maximum size of %d bytes", maxImportSize)))
+			return
+		}
+		_ = c.Error(errors.NewInvalidInput("file is required"))
+		return
+	}
+	if header.Size > maxImportSize {
+		_ = c.Error(errors.NewPayloadTooLarge(fmt.Sprintf("File exceeds the maximum size of %d bytes", maxImportSize)))
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(header.Filename), ".json") {
+			format = "json"
+		}
+	}
+	dryRun := false
+	if value := c.PostForm("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			_ = c.Error(errors.NewInvalidInput("dry_run must be true or false"))
+			return
+		}
+	}
+
+	ctx := context.Background()
+	fields, err := services.CustomFields(ctx)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	mapping, err := parseImportMapping(c.PostForm("mapping"), fields)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	var records []importRecord
+	switch format {
+	case "csv":
+		var required map[string]string
+		if c.PostForm("mapping") != "" {
+			required = mapping
+		}
+		records, err = readCSVImport(file, required)
+	case "json":
+		records, err = readJSONImport(file)
+	default:
+		err = errors.NewInvalidInput("format must be one of csv, json")
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	report, err := importTasks(ctx, c.GetString("username"), records, mapping, fields, dryRun)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// parseImportMapping parses the JSON mapping of task fields to source columns. Without
+// a mapping every task field and active custom field is read from the column of the
+// same name.
+func parseImportMapping(value string, fields map[string]*models.CustomField) (map[string]string, error) {
+	mapping := map[string]string{}
+	if value == "" {
+		for _, field := range models.ImportFields {
+			mapping[field] = field
+		}
+		for key, field := range fields {
+			if !field.Archived {
+				mapping[services.CustomFieldPrefix+key] = services.CustomFieldPrefix + key
+			}
+		}
+		return mapping, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+		return nil, errors.NewInvalidInput("mapping must be a JSON object of task fields to column names")
+	}
+	for target, column := range mapping {
+		if column == "" {
+			return nil, errors.NewInvalidInput("Column of field " + target + " must not be empty")
+		}
+		if key := strings.TrimPrefix(target, services.CustomFieldPrefix); key != target {
+			if field, ok := fields[key]; !ok || field.Archived {
+				return nil, errors.NewInvalidInput("Unknown custom field " + key)
+			}
+			continue
+		}
+		if !slices.Contains(models.ImportFields, target) {
+			return nil, errors.NewInvalidInput("Unknown field '" + target + "'. Allowed: " + strings.Join(models.ImportFields, ", ") + ", " + services.CustomFieldPrefix + "<key>")
+		}
+	}
+	return mapping, nil
+}
+
+// readCSVImport reads the rows of a CSV import. The first line names the columns, which
+// must include every column of the given mapping.
+func readCSVImport(r io.Reader, required map[string]string) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewInvalidInput("CSV file is empty")
+	}
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid CSV: " + err.Error())
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for _, column := range required {
+		if !slices.Contains(header, column) {
+			return nil, errors.NewInvalidInput("Column '" + column + "' not found in the CSV header")
+		}
+	}
+
+	var records []importRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewInvalidInput("Invalid CSV: " + err.Error())
+		}
+		if len(records) == models.MaxImportRows {
+			return nil, errors.NewInvalidInput(fmt.Sprintf("Import exceeds the maximum of %d rows", models.MaxImportRows))
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := importRecord{row: line, values: make(map[string]interface{}, len(header))}
+		for i, column := range header {
+			if i < len(values) {
+				record.values[column] = csvCellText(values[i])
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// readJSONImport reads the rows of a JSON import, given as an array of objects
+func readJSONImport(r io.Reader) ([]importRecord, error) {
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, errors.NewInvalidInput("JSON import must be an array of objects: " + err.Error())
+	}
+	if len(rows) > models.MaxImportRows {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("Import exceeds the maximum of %d rows", models.MaxImportRows))
+	}
+
+	records := make([]importRecord, len(rows))
+	for i, values := range rows {
+		records[i] = importRecord{row: i + 1, values: values}
+	}
+	return records, nil
+}
+
+// importTasks validates every record and, unless this is a dry run, inserts the valid
+// ones in batches
+func importTasks(ctx context.Context, actor string, records []importRecord, mapping map[string]string, fields map[string]*models.CustomField, dryRun bool) (*models.ImportReport, error) {
+	report := services.NewImportReport(dryRun, len(records))
+	tasks := make([]*models.Task, len(records))
+	for i, record := range records {
+		task, problems, err := importTask(ctx, record, mapping, fields)
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = services.ReportImportRow(report, i, record.row, task, problems)
+	}
+
+	if err := services.ApplyImport(ctx, actor, report, tasks); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importTask maps a record onto a new task. Problems with the row are returned as
+// messages; the error is only set if the row could not be checked.
+func importTask(ctx context.Context, record importRecord, mapping map[string]string, fields map[string]*models.CustomField) (*models.Task, []string, error) {
+	var row models.ImportRow
+	var problems []string
+
+	// Report problems in a stable order
+	targets := make([]string, 0, len(mapping))
+	for target := range mapping {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		value, ok := record.values[mapping[target]]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+
+		if key := strings.TrimPrefix(target, services.CustomFieldPrefix); key != target {
+			text, isText := value.(string)
+			if isText && fields[key].Type == models.FieldTypeNumber {
+				number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+				if err != nil {
+					problems = append(problems, "custom field "+key+" must be a number")
+					continue
+				}
+				value = number
+			}
+			if row.CustomFields == nil {
+				row.CustomFields = map[string]interface{}{}
+			}
+			row.CustomFields[key] = value
+			continue
+		}
+
+		if target == "labels" {
+			labels, err := importLabels(value)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			row.Labels = labels
+			continue
+		}
+
+		text, err := importText(target, value)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		switch target {
+		case "external_id":
+			row.ExternalID = text
+		case "title":
+			row.Title = text
+		case "description":
+			row.Description = text
+		case "status":
+			row.Status = text
+		case "priority":
+			row.Priority = text
+		case "assignee":
+			row.Assignee = text
+		case "project":
+			row.Project = text
+		case "parent_id":
+			row.ParentID = text
+		case "due_at":
+			row.DueAt = text
+		}
+	}
+
+	return services.ImportRowTask(ctx, &row, problems)
+}
+
+// importText converts a scalar source value into the text of a task field
+func importText(target string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("%s must be a string", target)
+}
+
+// importLabels reads labels given either as a comma-separated string, as in exports, or
+// as an array
+func importLabels(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		var labels []string
+		for _, label := range strings.Split(v, csvLabelSeparator) {
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, label)
+			}
+		}
+		return labels, nil
+	case []interface{}:
+		labels := make([]string, 0, len(v))
+		for _, item := range v {
+			label, err := importText("labels", item)
+			if err != nil {
+				return nil, fmt.Errorf("labels must be strings")
+			}
+			labels = append(labels, label)
+		}
+		return labels, nil
+	}
+	return nil, fmt.Errorf("labels must be a list of strings")
+}
+
+// @Summary Import tasks from another tracker
+// @Description Create tasks, with their comments, from the export file of another tracker: a Trello board JSON export,
+// @Description a Jira CSV export or a JSON array of GitHub issues from the REST API or gh issue list --json.
+// @Description A JSON mapping file translates Trello lists, Jira statuses and GitHub issue states to statuses and source
+// @Description users to usernames, e.g. {"statuses":{"Done":"completed"},"users":{"octocat":"johndoe"},"project":"website"}.
+// @Description Unmapped statuses start in the workflow's initial status, unmapped assignees are dropped and comments of
+// @Description unmapped authors are attributed to the caller. Items are identified by an external ID such as jira:PROJ-123,
+// @Description so importing the same file again skips the items already imported.
+// @Tags Tasks
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param source path string true "Tracker the file was exported from" Enums(trello, jira, github)
+// @Param file formData file true "Export file of at most 5000 items"
+// @Param mapping formData file false "JSON mapping file"
+// @Param dry_run formData bool false "Only validate the items"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} errors.AppError
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/import/{source} [post]
+func ImportTrackerTasks(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			_ = c.Error(errors.NewPayloadTooLarge(fmt.Sprintf("File exceeds the maximum size of %d bytes", maxImportSize)))
+			return
+		}
+		_ = c.Error(errors.NewInvalidInput("file is required"))
+		return
+	}
+
+	dryRun := false
+	if value := c.PostForm("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			_ = c.Error(errors.NewInvalidInput("dry_run must be true or false"))
+			return
+		}
+	}
+
+	mapping := &importers.Mapping{}
+	if mappingHeader, err := c.FormFile("mapping"); err == nil {
+		mappingFile, err := mappingHeader.Open()
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput(err.Error()))
+			return
+		}
+		mapping, err = importers.LoadMapping(mappingFile)
+		mappingFile.Close()
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput(err.Error()))
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	items, err := importers.Parse(c.Param("source"), file)
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	report, err := services.ImportTrackerItems(context.Background(), c.GetString("username"), items, mapping, dryRun)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/errors"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// parsePagination reads and validates the page and limit query parameters
+func parsePagination(c *gin.Context) (page, limit int, err error) {
+	page = 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return 0, 0, errors.NewInvalidInput("page must be a positive integer")
+		}
+	}
+	if limit, err = parseLimit(c); err != nil {
+		return 0, 0, err
+	}
+	return page, limit, nil
+}
+
+// parseLimit reads and validates the limit query parameter
+func parseLimit(c *gin.Context) (int, error) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, errors.NewInvalidInput("limit must be an integer between 1 and " + strconv.Itoa(maxPageLimit))
+	}
+	return limit, nil
+}
+
+// pageCursor is the decoded form of an opaque pagination cursor. It holds either the
+// sort key values of the last item returned, for keyset pagination, or an offset when
+// results are ranked by relevance and have no stable sort key.
+type pageCursor struct {
+	Sort   string `bson:"s"`
+	After  bson.A `bson:"a,omitempty"`
+	Offset int64  `bson:"o,omitempty"`
+}
+
+func (p pageCursor) encode() string {
+	data, err := bson.Marshal(p)
+	if err != nil {
+		// A cursor only holds values read back from the database
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes a cursor and checks that it was issued for the same sort
+func decodeCursor(value, sort string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid cursor")
+	}
+	var cursor pageCursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.NewInvalidInput("Invalid cursor")
+	}
+	if cursor.Sort != sort {
+		return nil, errors.NewInvalidInput("Cursor was issued for a different sort order")
+	}
+	return &cursor, nil
+}
+
+// sortKeyValues reads the values of the sort keys from a document. Missing fields are nil.
+func sortKeyValues(doc bson.Raw, sortKeys bson.D) bson.A {
+	values := make(bson.A, len(sortKeys))
+	for i, key := range sortKeys {
+		value, err := doc.LookupErr(strings.Split(key.Key, ".")...)
+		if err != nil {
+			continue
+		}
+		_ = value.Unmarshal(&values[i])
+	}
+	return values
+}
+
+// keysetFilter matches the documents that come after the given sort key values in the
+// given sort order. MongoDB sorts missing and null values before all others.
+func keysetFilter(sortKeys bson.D, after bson.A) bson.M {
+	var clauses bson.A
+	for i, key := range sortKeys {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[sortKeys[j].Key] = after[j]
+		}
+
+		value := after[i]
+		ascending := key.Value == 1
+		switch {
+		case ascending && value == nil:
+			clause[key.Key] = bson.M{"$ne": nil}
+		case ascending:
+			clause[key.Key] = bson.M{"$gt": value}
+		case value == nil:
+			// Nothing sorts after null in descending order
+			continue
+		default:
+			clause["$or"] = bson.A{bson.M{key.Key: bson.M{"$lt": value}}, bson.M{key.Key: nil}}
+		}
+		clauses = append(clauses, clause)
+	}
+	return bson.M{"$or": clauses}
+}
+
+// pageLink is a link to another page of the current request, given as overrides of its
+// query parameters. An empty value removes the parameter.
+type pageLink struct {
+	rel    string
+	params map[string]string
+}
+
+// linkHeader builds an RFC 8288 Link header value for the given pages
+func linkHeader(c *gin.Context, links []pageLink) string {
+	parts := make([]string, 0, len(links))
+	for _, link := range links {
+		u := *c.Request.URL
+		query := u.Query()
+		for key, value := range link.params {
+			if value == "" {
+				query.Del(key)
+			} else {
+				query.Set(key, value)
+			}
+		}
+		u.RawQuery = query.Encode()
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), link.rel))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin/binding"
+
+	"taskify/errors"
+	"taskify/models"
+)
+
+// Media types of the supported patch formats
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// patchDocument applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) to the
+// writable representation of a task and validates the result. Plain application/json
+// bodies are treated as merge patches.
+func patchDocument(contentType string, doc models.UpdateTaskDTO, patch []byte) (*models.UpdateTaskDTO, error) {
+	original, err := documentJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch contentType {
+	case mergePatchType, "application/json":
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case jsonPatchType:
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(patch); err == nil {
+			patched, err = ops.Apply(original)
+		}
+	default:
+		return nil, errors.NewUnsupportedMediaType("PATCH requires " + mergePatchType + " or " + jsonPatchType)
+	}
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid patch: " + err.Error())
+	}
+
+	var input models.UpdateTaskDTO
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		return nil, errors.NewInvalidInput("Invalid patch result: " + err.Error())
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, errors.NewInvalidInput(err.Error())
+	}
+	return &input, nil
+}
+
+// documentJSON encodes the document with every writable field present, empty ones as
+// null, so JSON Patch operations can address them
+func documentJSON(doc models.UpdateTaskDTO) ([]byte, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	docType := reflect.TypeOf(doc)
+	for i := 0; i < docType.NumField(); i++ {
+		name := strings.SplitN(docType.Field(i).Tag.Get("json"), ",", 2)[0]
+		if _, ok := fields[name]; !ok && name != "" && name != "-" {
+			fields[name] = nil
+		}
+	}
+	return json.Marshal(fields)
+}
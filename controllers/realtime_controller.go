@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"taskify/config"
+	"taskify/errors"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Connections are authenticated with a bearer token rather than cookies, so other
+	// origins cannot use a user's session
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Summary Collaborate in real time
+// @Description Open a WebSocket for real-time boards. Browsers pass the JWT as the access_token query parameter. Messages
+// @Description are JSON objects with a type. Clients send {"type":"subscribe","topic":"project:website"} or
+// @Description "task:<id>" to receive the events of a project's tasks or of a task, which are sent as {"type":"event",
+// @Description "topic":...,"event":"task.updated","data":{...}} with the same data as webhook payloads. On a subscribed
+// @Description topic, clients send {"type":"presence","state":"viewing"} or "left" and {"type":"typing","field":"title"};
+// @Description the server sends presence messages listing the users viewing the topic and relays typing signals to other
+// @Description users. Other messages are unsubscribe and ping; failures are reported with error messages.
+// @Tags Realtime
+// @Security BearerAuth
+// @Param access_token query string false "JWT, for clients that cannot set the Authorization header"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Router /ws [get]
+func ServeWebSocket(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied with an error
+		return
+	}
+
+	username := c.GetString("username")
+	config.Realtime.Serve(conn, username, func(topic string) error {
+		return authorizeTopic(c, topic)
+	})
+}
+
+// authorizeTopic returns an error unless the caller may subscribe to the topic
+func authorizeTopic(c *gin.Context, topic string) error {
+	kind, name, _ := strings.Cut(topic, ":")
+	switch {
+	case name == "":
+	case kind == "task":
+		id, err := primitive.ObjectIDFromHex(name)
+		if err != nil {
+			return errors.NewInvalidInput("Invalid task ID")
+		}
+		if err := authorizeTaskAction(c, "/tasks/:id", http.MethodGet); err != nil {
+			return err
+		}
+		_, err = findTask(context.Background(), id)
+		return err
+	case kind == "project":
+		return authorizeTaskAction(c, "/tasks", http.MethodGet)
+	}
+	return errors.NewInvalidInput("Topic must be task:<id> or project:<name>")
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+const maxPreviewOccurrences = 50
+
+// @Summary Preview task recurrence
+// @Description Get the due dates of the next occurrences of a recurring task
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param count query int false "Number of occurrences to return (max 50)" default(5)
+// @Success 200 {object} map[string][]time.Time
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/recurrence/preview [get]
+func PreviewRecurrence(c *gin.Context) {
+	count := 5
+	if countStr := c.Query("count"); countStr != "" {
+		var err error
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > maxPreviewOccurrences {
+			_ = c.Error(errors.NewInvalidInput("count must be an integer between 1 and " + strconv.Itoa(maxPreviewOccurrences)))
+			return
+		}
+	}
+
+	task, err := findRecurringTask(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	occurrences := []time.Time{}
+	if !task.Recurrence.Ended {
+		next, err := task.Recurrence.Occurrences(*task.DueAt, count)
+		if err != nil {
+			_ = c.Error(errors.NewInvalidInput(err.Error()))
+			return
+		}
+		occurrences = append(occurrences, next...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": occurrences,
+	})
+}
+
+// @Summary Stop a recurring series
+// @Description Stop generating further occurrences for the series the task belongs to. Existing occurrences are kept.
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/recurrence/stop [post]
+func StopRecurrence(c *gin.Context) {
+	task, err := findRecurringTask(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	if !task.Recurrence.Ended {
+		before := *task
+		recurrence := *task.Recurrence
+		before.Recurrence = &recurrence
+		task.Recurrence.Ended = true
+		// StopSeries increments the version of every task of the series
+		task.Version++
+
+		err := inTransaction(ctx, func(ctx context.Context) error {
+			if err := services.StopSeries(ctx, task.Recurrence.SeriesID); err != nil {
+				return err
+			}
+			return services.RecordTaskChanges(ctx, c.GetString("username"), &before, task)
+		})
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, task)
+}
+
+// findRecurringTask loads the task addressed by the id path parameter and checks that it recurs
+func findRecurringTask(c *gin.Context) (*models.Task, error) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid task ID format")
+	}
+
+	var task models.Task
+	err = config.DB.Collection("tasks").FindOne(context.Background(), bson.M{"_id": id, "deleted_at": nil}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewNotFound("Task")
+		}
+		return nil, errors.NewDatabaseError(err)
+	}
+	if task.Recurrence == nil || task.DueAt == nil {
+		return nil, errors.NewInvalidInput("Task does not recur")
+	}
+	return &task, nil
+}
@@ -2,8 +2,13 @@ package controllers
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,20 +18,32 @@ import (
 
 	"taskify/config"
 	"taskify/errors"
+	"taskify/events"
 	"taskify/models"
+	"taskify/services"
+	"taskify/utils"
 )
 
 // @Summary Get all tasks
-// @Description Get a list of all tasks with optional filtering, pagination, and sorting
+// @Description Get a list of all tasks with optional full-text search, filtering, pagination, and sorting.
+// @Description Results are paginated with an opaque cursor: pass next_cursor back as cursor to get the next page.
+// @Description When q is given, each task also carries a relevance score and highlighted snippets (see models.TaskSearchResultResponse).
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param status query string false "Filter by status (pending/in_progress/completed)"
-// @Param page query int false "Page number for pagination" default(1)
-// @Param limit query int false "Number of items per page" default(10)
-// @Param sort query string false "Sort field (created_at/-created_at)"
-// @Success 200 {array} models.TaskResponse
+// @Param q query string false "Full-text search over title, description and comments. Results are ranked by relevance and include highlighted snippets."
+// @Param status query string false "Filter by status, one of the statuses of the workflow"
+// @Param filter query string false "Filter expression, e.g. status in (pending, in_progress) and priority >= high and updated_at > -7d. Supports = != > >= < <=, [not] in (...), is [not] null, and/or/not and parentheses. Dates may be relative (-7d, +2w, -3h, now, today). At most 4096 bytes."
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param limit query int false "Number of items per page (max 100)" default(10)
+// @Param page query int false "Deprecated: page number for offset pagination. Use cursor instead." default(1)
+// @Param sort query string false "Comma-separated sort fields, prefixed with - for descending order, e.g. -priority,due_at,title. Allowed: assignee, created_at, due_at, priority, project, status, title, updated_at and cf.<key> for custom fields. Ties are broken by task ID."
+// @Param cf.key query string false "Filter by custom field value, e.g. cf.story_points=3"
+// @Param fields query string false "Comma-separated task fields to return, e.g. id,title,status. The id is always returned."
+// @Param include query string false "Comma-separated related data to embed: assignee (as assignee_user), comment_count, subtasks"
+// @Success 200 {object} models.TaskListResponse
+// @Header 200 {string} Link "RFC 8288 links to the first and next pages"
 // @Failure 400 {object} errors.AppError
 // @Failure 401 {object} errors.AppError "Unauthorized"
 // @Failure 500 {object} errors.AppError
@@ -35,38 +52,93 @@ func GetTasks(c *gin.Context) {
 	collection := config.DB.Collection("tasks")
 	ctx := context.Background()
 
-	// Build filter
-	filter := bson.M{}
-	if status := c.Query("status"); status != "" {
-		filter["status"] = status
+	filter, query, err := taskListFilter(ctx, c)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
 
-	// Pagination
-	page := 1
-	if pageStr := c.Query("page"); pageStr != "" {
-		page, _ = strconv.Atoi(pageStr)
+	// Sort, ranking search results by relevance unless another order is requested
+	findOptions := options.Find()
+	sort, sortKeys, err := taskListSort(ctx, c, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
-	limit := 10
-	if limitStr := c.Query("limit"); limitStr != "" {
-		limit, _ = strconv.Atoi(limitStr)
+	if sortKeys != nil {
+		findOptions.SetSort(sortKeys)
+	} else {
+		findOptions.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}})
 	}
-	skip := (page - 1) * limit
 
-	// Build options
-	findOptions := options.Find()
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetLimit(int64(limit))
-
-	// Sort
-	if sort := c.Query("sort"); sort != "" {
-		order := 1
-		if sort[0] == '-' {
-			order = -1
-			sort = sort[1:]
+	// Project only the requested fields, plus the sort keys the next cursor is built from
+	// and the fields search snippets are taken from
+	view, err := services.ParseTaskView(c.Query("fields"), c.Query("include"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	var extra []string
+	for _, key := range sortKeys {
+		extra = append(extra, key.Key)
+	}
+	if query != "" {
+		extra = append(extra, "title", "description", "comment_text")
+	}
+	projection := view.Projection(extra...)
+	if query != "" {
+		if projection == nil {
+			projection = bson.M{}
+		}
+		projection["score"] = bson.M{"$meta": "textScore"}
+	}
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	limit, err := parseLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	// Deprecated page-based pagination
+	page := 0
+	if c.Query("page") != "" {
+		if page, _, err = parsePagination(c); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		findOptions.SetSkip(int64((page - 1) * limit))
+	}
+
+	// Cursor pagination continues after the sort key of the last task of the previous
+	// page, or after an offset when ranking by relevance
+	var after *pageCursor
+	if value := c.Query("cursor"); value != "" && page == 0 {
+		if after, err = decodeCursor(value, sort); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		if sortKeys == nil {
+			findOptions.SetSkip(after.Offset)
+		} else if len(after.After) != len(sortKeys) {
+			_ = c.Error(errors.NewInvalidInput("Invalid cursor"))
+			return
+		} else {
+			clauses, _ := filter["$and"].(bson.A)
+			filter["$and"] = append(clauses, keysetFilter(sortKeys, after.After))
 		}
-		findOptions.SetSort(bson.D{{Key: sort, Value: order}})
 	}
 
+	// Fetch one extra task to tell whether there is a next page
+	findOptions.SetLimit(int64(limit + 1))
 	cursor, err := collection.Find(ctx, filter, findOptions)
 	if err != nil {
 		_ = c.Error(errors.NewDatabaseError(err))
@@ -74,13 +146,138 @@ func GetTasks(c *gin.Context) {
 	}
 	defer cursor.Close(ctx)
 
-	var tasks []models.Task
-	if err := cursor.All(ctx, &tasks); err != nil {
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
 		_ = c.Error(errors.NewDatabaseError(err))
 		return
 	}
+	hasMore := len(docs) > limit
+	if hasMore {
+		docs = docs[:limit]
+	}
+
+	items := make([]interface{}, len(docs))
+	tasks := make([]*models.Task, len(docs))
+	terms := utils.SearchTerms(query)
+	for i, doc := range docs {
+		if query != "" {
+			result := &models.TaskSearchResult{}
+			if err := bson.Unmarshal(doc, result); err != nil {
+				_ = c.Error(errors.NewDatabaseError(err))
+				return
+			}
+			result.Highlight(terms)
+			items[i], tasks[i] = result, &result.Task
+			continue
+		}
+
+		task := &models.Task{}
+		if err := bson.Unmarshal(doc, task); err != nil {
+			_ = c.Error(errors.NewDatabaseError(err))
+			return
+		}
+		items[i], tasks[i] = task, task
+	}
+
+	var data interface{} = items
+	if !view.IsFull() {
+		if data, err = view.Render(ctx, items, tasks); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	if page > 0 {
+		c.Header("Deprecation", "true")
+		links := []pageLink{{rel: "first", params: map[string]string{"page": "1"}}}
+		if page > 1 {
+			links = append(links, pageLink{rel: "prev", params: map[string]string{"page": strconv.Itoa(page - 1)}})
+		}
+		if hasMore {
+			links = append(links, pageLink{rel: "next", params: map[string]string{"page": strconv.Itoa(page + 1)}})
+		}
+		lastPage := (total + int64(limit) - 1) / int64(limit)
+		if lastPage < 1 {
+			lastPage = 1
+		}
+		links = append(links, pageLink{rel: "last", params: map[string]string{"page": strconv.FormatInt(lastPage, 10)}})
+		c.Header("Link", linkHeader(c, links))
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":  data,
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		})
+		return
+	}
+
+	var nextCursor interface{}
+	links := []pageLink{{rel: "first", params: map[string]string{"cursor": ""}}}
+	if hasMore {
+		next := pageCursor{Sort: sort}
+		if sortKeys == nil {
+			if after != nil {
+				next.Offset = after.Offset
+			}
+			next.Offset += int64(limit)
+		} else {
+			next.After = sortKeyValues(docs[len(docs)-1], sortKeys)
+		}
+		encoded := next.encode()
+		nextCursor = encoded
+		links = append(links, pageLink{rel: "next", params: map[string]string{"cursor": encoded}})
+	}
+	c.Header("Link", linkHeader(c, links))
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, gin.H{
+		"data":        data,
+		"next_cursor": nextCursor,
+		"total":       total,
+	})
+}
+
+// taskListFilter builds the filter of a task listing from the query parameters shared by
+// GetTasks and ExportTasks, excluding tasks in the trash. It also returns the full-text
+// search query, if any.
+func taskListFilter(ctx context.Context, c *gin.Context) (bson.M, string, error) {
+	filter, err := services.CustomFieldFilter(ctx, c.Request.URL.Query())
+	if err != nil {
+		return nil, "", err
+	}
+	filter["deleted_at"] = nil
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if expr := c.Query("filter"); expr != "" {
+		parsed, err := services.ParseTaskFilter(ctx, expr)
+		if err != nil {
+			return nil, "", err
+		}
+		filter["$and"] = bson.A{parsed}
+	}
+
+	// Full-text search over title, description and comments
+	query := strings.TrimSpace(c.Query("q"))
+	if query != "" {
+		filter["$text"] = bson.M{"$search": query}
+	}
+	return filter, query, nil
+}
+
+// taskListSort returns the sort parameter of a task listing and its sort keys. Without a
+// sort parameter, search results are ranked by relevance, returned as the "score" sort
+// with nil keys, and other listings are sorted by ID.
+func taskListSort(ctx context.Context, c *gin.Context, query string) (string, bson.D, error) {
+	sort := c.Query("sort")
+	switch {
+	case sort != "":
+		sortKeys, err := services.TaskSort(ctx, sort)
+		return sort, sortKeys, err
+	case query != "":
+		return "score", nil, nil
+	}
+	return "", bson.D{{Key: "_id", Value: 1}}, nil
 }
 
 // @Summary Create a new task
@@ -90,42 +287,53 @@ func GetTasks(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param task body models.CreateTaskDTO true "Task object"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
 // @Success 201 {object} models.TaskResponse
+// @Header 201 {string} ETag "Version of the task"
 // @Failure 400 {object} errors.AppError
 // @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
 // @Failure 500 {object} errors.AppError
 // @Router /tasks [post]
 func CreateTask(c *gin.Context) {
-	var input struct {
-		Title       string `json:"title" binding:"required,min=3,max=100"`
-		Description string `json:"description,omitempty" binding:"omitempty,max=500"`
-		Status      string `json:"status,omitempty" binding:"omitempty,oneof=pending in_progress completed"`
-	}
-
+	var input models.CreateTaskDTO
 	if err := c.ShouldBindJSON(&input); err != nil {
 		_ = c.Error(errors.NewInvalidInput(err.Error()))
 		return
 	}
 
-	task := models.NewTask(input.Title)
-	if input.Description != "" {
-		task.Description = input.Description
-	}
-	if input.Status != "" {
-		task.Status = input.Status
+	task, err := createTask(context.Background(), c.GetString("username"), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
 
-	collection := config.DB.Collection("tasks")
-	ctx := context.Background()
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusCreated, task)
+}
 
-	result, err := collection.InsertOne(ctx, task)
+// createTask validates the input against the task's workflow and custom fields and
+// inserts the new task
+func createTask(ctx context.Context, actor string, input models.CreateTaskDTO) (*models.Task, error) {
+	task, err := services.NewTask(ctx, input)
 	if err != nil {
-		_ = c.Error(errors.NewDatabaseError(err))
-		return
+		return nil, err
 	}
+	if err := insertTask(ctx, actor, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
 
-	task.ID = result.InsertedID.(primitive.ObjectID)
-	c.JSON(http.StatusCreated, task)
+// insertTask inserts a task built by services.NewTask and records its creation
+func insertTask(ctx context.Context, actor string, task *models.Task) error {
+	return inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := config.DB.Collection("tasks").InsertOne(ctx, task); err != nil {
+			return err
+		}
+		return services.RecordTaskChanges(ctx, actor, nil, task)
+	})
 }
 
 // @Summary Get a task by ID
@@ -135,7 +343,10 @@ func CreateTask(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Task ID"
+// @Param fields query string false "Comma-separated task fields to return, e.g. id,title,status. The id is always returned."
+// @Param include query string false "Comma-separated related data to embed: assignee (as assignee_user), comment_count, subtasks"
 // @Success 200 {object} models.TaskResponse
+// @Header 200 {string} ETag "Version of the task"
 // @Failure 400 {object} errors.AppError
 // @Failure 401 {object} errors.AppError "Unauthorized"
 // @Failure 404 {object} errors.AppError
@@ -148,11 +359,22 @@ func GetTask(c *gin.Context) {
 		return
 	}
 
+	view, err := services.ParseTaskView(c.Query("fields"), c.Query("include"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	collection := config.DB.Collection("tasks")
 	ctx := context.Background()
 
+	findOptions := options.FindOne()
+	if projection := view.Projection("version"); projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
 	var task models.Task
-	err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	err = collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}, findOptions).Decode(&task)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			_ = c.Error(errors.NewNotFound("Task"))
@@ -162,81 +384,219 @@ func GetTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	c.Header("ETag", taskETag(task.Version))
+	if view.IsFull() {
+		c.JSON(http.StatusOK, task)
+		return
+	}
+	views, err := view.Render(ctx, []interface{}{&task}, []*models.Task{&task})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, views[0])
 }
 
-// @Summary Update a task
-// @Description Update a task's information
+// @Summary Replace a task
+// @Description Replace a task's writable fields. Omitted fields are cleared; use PATCH for partial updates.
+// @Description Status changes must follow the transitions of the task's workflow.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Task ID"
-// @Param task body models.CreateTaskDTO true "Task object"
+// @Param If-Match header string false "ETag of the version the change is based on; the change fails with 412 if the task has changed since"
+// @Param task body models.UpdateTaskDTO true "Task object"
 // @Success 200 {object} models.TaskResponse
+// @Header 200 {string} ETag "Version of the task"
 // @Failure 400 {object} errors.AppError
 // @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Status transition not allowed for role"
 // @Failure 404 {object} errors.AppError
+// @Failure 412 {object} errors.AppError "Task has been modified"
 // @Failure 500 {object} errors.AppError
 // @Router /tasks/{id} [put]
 func UpdateTask(c *gin.Context) {
-	id := c.Param("id")
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		_ = c.Error(errors.NewInvalidInput("invalid task ID"))
 		return
 	}
 
-	var input struct {
-		Title       string `json:"title,omitempty" binding:"omitempty,min=3,max=100"`
-		Description string `json:"description,omitempty" binding:"omitempty,max=500"`
-		Status      string `json:"status,omitempty" binding:"omitempty,oneof=pending in_progress completed"`
-	}
-
+	var input models.UpdateTaskDTO
 	if err := c.ShouldBindJSON(&input); err != nil {
 		_ = c.Error(errors.NewInvalidInput(err.Error()))
 		return
 	}
 
-	collection := config.DB.Collection("tasks")
 	ctx := context.Background()
+	task, err := findTask(ctx, objectID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := checkIfMatch(c, task.Version); err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	var task models.Task
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&task)
+	if err := replaceTask(ctx, c.GetString("username"), c.GetString("role"), task, input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, task)
+}
+
+// @Summary Patch a task
+// @Description Partially update a task with an RFC 7396 JSON Merge Patch (application/merge-patch+json or application/json),
+// @Description where null clears a field, or an RFC 6902 JSON Patch (application/json-patch+json).
+// @Description Patches apply to the task's writable fields as in models.UpdateTaskDTO, and the result is validated like a PUT.
+// @Tags Tasks
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param If-Match header string false "ETag of the version the change is based on; the change fails with 412 if the task has changed since"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.TaskResponse
+// @Header 200 {string} ETag "Version of the task"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Status transition not allowed for role"
+// @Failure 404 {object} errors.AppError
+// @Failure 415 {object} errors.AppError "Unsupported patch format"
+// @Failure 412 {object} errors.AppError "Task has been modified"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id} [patch]
+func PatchTask(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			_ = c.Error(errors.NewNotFound("task not found"))
-			return
-		}
-		_ = c.Error(errors.NewDatabaseError(err))
+		_ = c.Error(errors.NewInvalidInput("invalid task ID"))
 		return
 	}
 
-	if err := task.Update(input.Title, input.Description, input.Status); err != nil {
-		_ = c.Error(errors.NewInvalidInput(err.Error()))
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Failed to read request body"))
 		return
 	}
 
-	_, err = collection.ReplaceOne(ctx, bson.M{"_id": objectID}, task)
+	ctx := context.Background()
+	task, err := findTask(ctx, objectID)
 	if err != nil {
-		_ = c.Error(errors.NewDatabaseError(err))
+		_ = c.Error(err)
+		return
+	}
+	if err := checkIfMatch(c, task.Version); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	input, err := patchDocument(c.ContentType(), task.Document(), patch)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := replaceTask(ctx, c.GetString("username"), c.GetString("role"), task, *input); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
+	c.Header("ETag", taskETag(task.Version))
 	c.JSON(http.StatusOK, task)
 }
 
+// findTask loads a task that is not in the trash
+func findTask(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
+	var task models.Task
+	err := config.DB.Collection("tasks").FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.NewNotFound("Task")
+		}
+		return nil, errors.NewDatabaseError(err)
+	}
+	return &task, nil
+}
+
+// replaceTask replaces the writable fields of the task with the input and saves it,
+// failing if the task was changed since it was read
+func replaceTask(ctx context.Context, actor, role string, task *models.Task, input models.UpdateTaskDTO) error {
+	if input.Assignee != "" && input.Assignee != task.Assignee {
+		if err := services.EnsureUserExists(ctx, input.Assignee); err != nil {
+			return err
+		}
+	}
+
+	workflow, err := services.WorkflowFor(ctx, task.Project)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if input.Status != task.Status {
+		if err := workflow.CheckTransition(task.Status, input.Status, role); err != nil {
+			return transitionError(err)
+		}
+	}
+
+	before := *task
+	before.CustomFields = copyCustomFields(task.CustomFields)
+	if err := task.Replace(input); err != nil {
+		return errors.NewInvalidInput(err.Error())
+	}
+	if err := services.ReplaceCustomFields(ctx, task, input.CustomFields); err != nil {
+		return err
+	}
+
+	// Only write if nobody else changed the task since it was read
+	filter := bson.M{"_id": task.ID, "version": versionFilter(task.Version), "deleted_at": nil}
+	task.Version++
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.DB.Collection("tasks").ReplaceOne(ctx, filter, task)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errors.NewPreconditionFailed("Task was modified by another request; reload it and try again")
+		}
+		return services.RecordTaskChanges(ctx, actor, &before, task)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Completing an occurrence of a recurring task generates the next one
+	if !workflow.IsDone(before.Status) && workflow.IsDone(task.Status) && task.Recurrence != nil {
+		if _, err := services.GenerateNextOccurrence(ctx, task, actor); err != nil {
+			log.Printf("Error: failed to generate next occurrence of task %s: %v", task.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
 // @Summary Delete a task
-// @Description Delete a task by ID
+// @Description Move a task to the trash. Trashed tasks can be restored until they are purged.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Task ID"
+// @Param If-Match header string false "ETag of the version the change is based on; the change fails with 412 if the task has changed since"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
 // @Success 204 "No Content"
 // @Failure 400 {object} errors.AppError
 // @Failure 401 {object} errors.AppError "Unauthorized"
 // @Failure 404 {object} errors.AppError
+// @Failure 412 {object} errors.AppError "Task has been modified"
+// @Failure 409 {object} errors.AppError "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} errors.AppError "Idempotency-Key reused for a different request"
 // @Failure 500 {object} errors.AppError
 // @Router /tasks/{id} [delete]
 func DeleteTask(c *gin.Context) {
@@ -246,19 +606,79 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	collection := config.DB.Collection("tasks")
 	ctx := context.Background()
-
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	task, err := findTask(ctx, id)
 	if err != nil {
-		_ = c.Error(errors.NewDatabaseError(err))
+		_ = c.Error(err)
+		return
+	}
+	if err := checkIfMatch(c, task.Version); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	if result.DeletedCount == 0 {
-		_ = c.Error(errors.NewNotFound("Task"))
+	if err := deleteTask(ctx, c.GetString("username"), task); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// deleteTask moves the task to the trash, failing if it was changed since it was read
+func deleteTask(ctx context.Context, actor string, task *models.Task) error {
+	now := time.Now()
+	deleted := *task
+	deleted.DeletedAt, deleted.DeletedBy, deleted.UpdatedAt = &now, actor, now
+	deleted.Version++
+
+	return inTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.DB.Collection("tasks").UpdateOne(ctx,
+			bson.M{"_id": task.ID, "version": versionFilter(task.Version), "deleted_at": nil},
+			bson.M{
+				"$set": bson.M{"deleted_at": now, "deleted_by": actor, "updated_at": now},
+				"$inc": bson.M{"version": 1},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errors.NewPreconditionFailed("Task was modified by another request; reload it and try again")
+		}
+		return services.RecordEvent(ctx, events.NewTaskDeleted(actor, &deleted))
+	})
+}
+
+// inTransaction runs fn in a transaction with the events it records, reporting errors
+// other than application errors as database errors. fn returns database errors as they
+// are, so that transient ones retry the transaction.
+func inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := services.WithTransaction(ctx, fn)
+	var appErr *errors.AppError
+	if err == nil || stderrors.As(err, &appErr) {
+		return err
+	}
+	return errors.NewDatabaseError(err)
+}
+
+// transitionError converts a rejected status change into the matching application error
+func transitionError(err error) *errors.AppError {
+	var transitionErr *models.TransitionError
+	if stderrors.As(err, &transitionErr) && transitionErr.Forbidden {
+		return errors.NewForbidden(err.Error())
+	}
+	return errors.NewInvalidInput(err.Error())
+}
+
+// copyCustomFields returns a shallow copy of a task's custom field values
+func copyCustomFields(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/events"
+	"taskify/models"
+	"taskify/services"
+)
+
+// @Summary List trashed tasks
+// @Description Get the tasks in the trash, most recently deleted first
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number for pagination" default(1)
+// @Param limit query int false "Number of items per page (max 100)" default(10)
+// @Success 200 {object} models.TrashListResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /trash [get]
+func GetTrash(c *gin.Context) {
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	collection := config.DB.Collection("tasks")
+	ctx := context.Background()
+	filter := bson.M{"deleted_at": bson.M{"$ne": nil}}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  tasks,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// @Summary Restore a task
+// @Description Move a task out of the trash
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /tasks/{id}/restore [post]
+func RestoreTask(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	collection := config.DB.Collection("tasks")
+	ctx := context.Background()
+
+	var task models.Task
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		err := collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+			bson.M{
+				"$unset":       bson.M{"deleted_at": "", "deleted_by": ""},
+				"$currentDate": bson.M{"updated_at": true},
+				"$inc":         bson.M{"version": 1},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&task)
+		if err == mongo.ErrNoDocuments {
+			return errors.NewNotFound("Trashed task")
+		}
+		if err != nil {
+			return err
+		}
+		return services.RecordEvent(ctx, events.NewTaskRestored(c.GetString("username"), &task))
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// @Summary Permanently delete a task
+// @Description Permanently delete a trashed task together with its comments and attachments. Admin only.
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /trash/{id} [delete]
+func PurgeTask(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid task ID format"))
+		return
+	}
+
+	err = services.PurgeTask(context.Background(), id, c.GetString("username"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			_ = c.Error(errors.NewNotFound("Trashed task"))
+			return
+		}
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+// @Summary List webhooks
+// @Description Get all webhook subscriptions. Secrets are not included.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookResponse
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks [get]
+func GetWebhooks(c *gin.Context) {
+	ctx := context.Background()
+	cursor, err := config.DB.Collection("webhooks").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Create a webhook
+// @Description Subscribe a URL to task events. Each event is POSTed as JSON with its name in the X-Taskify-Event header
+// @Description and the HMAC-SHA256 of the body, keyed with the secret, in the X-Taskify-Signature-256 header as sha256=<hex>.
+// @Description Deliveries that fail or get a non-2xx response are retried with exponential backoff; webhooks whose
+// @Description deliveries keep failing are disabled. The payload id identifies the event and stays the same across retries.
+// @Description Only public addresses are delivered to, and redirects are not followed.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body models.CreateWebhookDTO true "Webhook"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 201 {object} models.WebhookResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	var input models.CreateWebhookDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	webhook, err := models.NewWebhook(input, c.GetString("username"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	result, err := config.DB.Collection("webhooks").InsertOne(context.Background(), webhook)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// @Summary Get a webhook
+// @Description Get a webhook subscription by ID
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks/{id} [get]
+func GetWebhook(c *gin.Context) {
+	webhook, err := findWebhook(context.Background(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Update a webhook
+// @Description Replace a webhook's URL, events and active state, and optionally its secret. Activating a webhook that was
+// @Description disabled after failing resets its failure count.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param webhook body models.UpdateWebhookDTO true "Webhook"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 200 {object} models.WebhookResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks/{id} [put]
+func UpdateWebhook(c *gin.Context) {
+	var input models.UpdateWebhookDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	ctx := context.Background()
+	webhook, err := findWebhook(ctx, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := webhook.Update(input); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	if _, err := config.DB.Collection("webhooks").ReplaceOne(ctx, bson.M{"_id": webhook.ID}, webhook); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Delete a webhook
+// @Description Delete a webhook subscription together with its delivery log
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 204 "No Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid webhook ID format"))
+		return
+	}
+
+	ctx := context.Background()
+	result, err := config.DB.Collection("webhooks").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	if result.DeletedCount == 0 {
+		_ = c.Error(errors.NewNotFound("Webhook"))
+		return
+	}
+	if _, err := config.DB.Collection("webhook_deliveries").DeleteMany(ctx, bson.M{"webhook_id": id}); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List webhook deliveries
+// @Description Get the delivery log of a webhook, newest first, with every attempt and the response it got
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Only deliveries with this status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number for pagination" default(1)
+// @Param limit query int false "Number of items per page (max 100)" default(10)
+// @Success 200 {object} models.WebhookDeliveryListResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	webhook, err := findWebhook(ctx, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter := bson.M{"webhook_id": webhook.ID}
+	switch status := c.Query("status"); status {
+	case "":
+	case models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+		filter["status"] = status
+	default:
+		_ = c.Error(errors.NewInvalidInput("status must be one of: pending, succeeded, failed"))
+		return
+	}
+
+	collection := config.DB.Collection("webhook_deliveries")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  deliveries,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// @Summary Redeliver a webhook event
+// @Description Queue a new delivery of the event of an earlier delivery, with the same payload and event id, e.g. after
+// @Description fixing the receiver. The webhook must be active.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; the first response is replayed for retries with the same key"
+// @Success 202 {object} models.WebhookDeliveryResponse
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 404 {object} errors.AppError
+// @Failure 409 {object} errors.AppError "Webhook is disabled"
+// @Failure 500 {object} errors.AppError
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	deliveryID, err := primitive.ObjectIDFromHex(c.Param("delivery_id"))
+	if err != nil {
+		_ = c.Error(errors.NewInvalidInput("Invalid delivery ID format"))
+		return
+	}
+
+	ctx := context.Background()
+	webhook, err := findWebhook(ctx, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if !webhook.Active {
+		_ = c.Error(errors.NewConflict("Webhook is disabled; activate it before redelivering events"))
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	err = config.DB.Collection("webhook_deliveries").FindOne(ctx,
+		bson.M{"_id": deliveryID, "webhook_id": webhook.ID}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		_ = c.Error(errors.NewNotFound("Delivery"))
+		return
+	}
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	redelivery, err := services.Redeliver(ctx, &delivery)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, redelivery)
+}
+
+// findWebhook loads a webhook by its ID in hex form
+func findWebhook(ctx context.Context, hexID string) (*models.Webhook, error) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, errors.NewInvalidInput("Invalid webhook ID format")
+	}
+
+	var webhook models.Webhook
+	err = config.DB.Collection("webhooks").FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.NewNotFound("Webhook")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	return &webhook, nil
+}
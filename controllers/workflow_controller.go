@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+	"taskify/services"
+)
+
+// @Summary Get the default workflow
+// @Description Get the workflow used by tasks that do not belong to a project with its own workflow
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Router /workflows/default [get]
+func GetDefaultWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, config.DefaultWorkflow)
+}
+
+// @Summary Get a project's workflow
+// @Description Get the workflow used by the tasks of a project. Projects without their own workflow use the default one.
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project path string true "Project key"
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 500 {object} errors.AppError
+// @Router /projects/{project}/workflow [get]
+func GetProjectWorkflow(c *gin.Context) {
+	workflow, err := services.WorkflowFor(context.Background(), c.Param("project"))
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary Set a project's workflow
+// @Description Define the statuses and allowed transitions for the tasks of a project. Tasks of the project must not use statuses the new workflow does not define.
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project path string true "Project key"
+// @Param workflow body models.Workflow true "Workflow definition"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 500 {object} errors.AppError
+// @Router /projects/{project}/workflow [put]
+func SetProjectWorkflow(c *gin.Context) {
+	var workflow models.Workflow
+	if err := c.ShouldBindJSON(&workflow); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+	if err := workflow.Validate(); err != nil {
+		_ = c.Error(errors.NewInvalidInput(err.Error()))
+		return
+	}
+
+	project := c.Param("project")
+	ctx := context.Background()
+	if err := checkProjectStatuses(ctx, project, &workflow); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_, err := config.DB.Collection("workflows").ReplaceOne(ctx,
+		bson.M{"_id": project},
+		models.ProjectWorkflow{Project: project, Workflow: workflow},
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary Remove a project's workflow
+// @Description Make the tasks of a project use the default workflow again
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project path string true "Project key"
+// @Success 204 "No Content"
+// @Failure 400 {object} errors.AppError
+// @Failure 401 {object} errors.AppError "Unauthorized"
+// @Failure 403 {object} errors.AppError "Forbidden"
+// @Failure 500 {object} errors.AppError
+// @Router /projects/{project}/workflow [delete]
+func DeleteProjectWorkflow(c *gin.Context) {
+	project := c.Param("project")
+	ctx := context.Background()
+	if err := checkProjectStatuses(ctx, project, config.DefaultWorkflow); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if _, err := config.DB.Collection("workflows").DeleteOne(ctx, bson.M{"_id": project}); err != nil {
+		_ = c.Error(errors.NewDatabaseError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkProjectStatuses rejects a workflow that does not define every status in use by the project's tasks
+func checkProjectStatuses(ctx context.Context, project string, workflow *models.Workflow) error {
+	unknown, err := services.UnknownStatuses(ctx, project, workflow)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if len(unknown) > 0 {
+		return errors.NewInvalidInput("Tasks of project " + project + " use statuses not defined by the workflow: " + strings.Join(unknown, ", "))
+	}
+	return nil
+}
@@ -49,6 +49,60 @@ func NewInvalidInput(message string) *AppError {
 	}
 }
 
+// NewForbidden creates a new forbidden error
+func NewForbidden(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusForbidden,
+	}
+}
+
+// NewPayloadTooLarge creates a new payload too large error
+func NewPayloadTooLarge(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// NewUnsupportedMediaType creates a new unsupported media type error
+func NewUnsupportedMediaType(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusUnsupportedMediaType,
+	}
+}
+
+// NewPreconditionFailed creates a new precondition failed error
+func NewPreconditionFailed(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusPreconditionFailed,
+	}
+}
+
+// NewConflict creates a new conflict error
+func NewConflict(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusConflict,
+	}
+}
+
+// NewUnprocessableEntity creates a new unprocessable entity error
+func NewUnprocessableEntity(message string) *AppError {
+	return &AppError{
+		Err:        ErrInvalidInput,
+		Message:    message,
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+}
+
 // NewDatabaseError creates a new database error
 func NewDatabaseError(err error) *AppError {
 	return &AppError{
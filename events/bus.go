@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+)
+
+const (
+	// asyncShards is the number of goroutines handling each asynchronous subscriber's
+	// events; the events of an aggregate are always handled by the same one
+	asyncShards = 8
+	// asyncQueueSize is the number of events each shard queues before publishers wait
+	asyncQueueSize = 256
+)
+
+// Handler handles a published event. Handlers receive every event and ignore the ones
+// they are not interested in; they must not publish events themselves.
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// handle runs the handler, logging its error or panic so that a failing subscriber
+// affects neither the publisher nor the other subscribers. The error is returned for
+// publishers that retry failed events.
+func (s *subscriber) handle(ctx context.Context, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+		if err != nil {
+			err = fmt.Errorf("event subscriber %s failed on %s %s: %w", s.name, event.EventName(), event.EventID(), err)
+			log.Printf("Error: %v", err)
+		}
+	}()
+	return s.handler(ctx, event)
+}
+
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+// asyncSubscriber queues events for a subscriber handled in the background
+type asyncSubscriber struct {
+	subscriber
+	shards [asyncShards]chan delivery
+}
+
+// Bus delivers domain events to the subscribers registered on it. Synchronous
+// subscribers run before Publish returns; asynchronous ones run in the background.
+// Subscribers must be registered before events are published.
+type Bus struct {
+	sync  []*subscriber
+	async []*asyncSubscriber
+
+	// Serializes the publication of an aggregate's events, so that every subscriber
+	// receives them in the same order
+	locks  [asyncShards]sync.Mutex
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler run synchronously by Publish. Its failure does not keep
+// the other subscribers from running, but is reported by Publish.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.sync = append(b.sync, &subscriber{name: name, handler: handler})
+}
+
+// SubscribeAsync registers a handler run in the background, for work that the
+// publisher should not wait for. The handler's context is not cancelled with the
+// publisher's.
+func (b *Bus) SubscribeAsync(name string, handler Handler) {
+	s := &asyncSubscriber{subscriber: subscriber{name: name, handler: handler}}
+	for i := range s.shards {
+		queue := make(chan delivery, asyncQueueSize)
+		s.shards[i] = queue
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for d := range queue {
+				_ = s.handle(d.ctx, d.event)
+			}
+		}()
+	}
+	b.async = append(b.async, s)
+}
+
+// Publish delivers the event to the synchronous subscribers, in the order they were
+// registered, and queues it for the asynchronous ones. It waits for room in full
+// queues until ctx is done, when the event is dropped for those subscribers. The errors
+// of synchronous subscribers are returned once all of them have run.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("event bus is closed")
+	}
+
+	shard := shardOf(event.AggregateID())
+	b.locks[shard].Lock()
+	defer b.locks[shard].Unlock()
+
+	var errs []error
+	for _, s := range b.sync {
+		if err := s.handle(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	d := delivery{ctx: context.WithoutCancel(ctx), event: event}
+	for _, s := range b.async {
+		select {
+		case s.shards[shard] <- d:
+		case <-ctx.Done():
+			log.Printf("Error: event %s %s dropped for subscriber %s: %v", event.EventName(), event.EventID(), s.name, ctx.Err())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting events and waits for the asynchronous subscribers to handle the
+// queued ones, or for ctx to be done
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, s := range b.async {
+			for _, queue := range s.shards {
+				close(queue)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event subscribers did not finish: %w", ctx.Err())
+	}
+}
+
+// shardOf returns the shard handling an aggregate's events
+func shardOf(aggregateID string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(aggregateID))
+	return int(hash.Sum32() % asyncShards)
+}
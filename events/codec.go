@@ -0,0 +1,38 @@
+package events
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/models"
+)
+
+// types creates an empty event of each name, for decoding stored events
+var types = map[string]func() Event{
+	models.EventTaskCreated:   func() Event { return &TaskCreated{} },
+	models.EventTaskUpdated:   func() Event { return &TaskUpdated{} },
+	models.EventTaskDeleted:   func() Event { return &TaskDeleted{} },
+	models.EventTaskRestored:  func() Event { return &TaskRestored{} },
+	models.EventTaskPurged:    func() Event { return &TaskPurged{} },
+	models.EventTaskCommented: func() Event { return &TaskCommented{} },
+	UserRegisteredEvent:       func() Event { return &UserRegistered{} },
+}
+
+// Encode returns the BSON document of an event, to store it
+func Encode(event Event) (bson.Raw, error) {
+	return bson.Marshal(event)
+}
+
+// Decode returns the event of the given name stored as data by Encode
+func Decode(name string, data bson.Raw) (Event, error) {
+	newEvent, ok := types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %s", name)
+	}
+	event := newEvent()
+	if err := bson.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"taskify/models"
+)
+
+// UserRegisteredEvent is the name of the UserRegistered event; task events are named
+// after the models.EventTask constants
+const UserRegisteredEvent = "user.registered"
+
+// Event is something that happened in the domain, published after the change it
+// describes was written
+type Event interface {
+	// EventID identifies the event, so that consumers can discard duplicates
+	EventID() string
+	// EventName is the name of the event, e.g. task.updated
+	EventName() string
+	// AggregateID identifies the entity the event is about. Each subscriber receives the
+	// events of an aggregate in the order they were published.
+	AggregateID() string
+	// OccurredAt is when the change happened
+	OccurredAt() time.Time
+}
+
+// Metadata holds the fields common to all events
+type Metadata struct {
+	ID string `json:"id" bson:"id"`
+	// User that made the change
+	Actor string    `json:"actor" bson:"actor"`
+	At    time.Time `json:"at" bson:"at"`
+}
+
+func newMetadata(actor string) Metadata {
+	return Metadata{ID: primitive.NewObjectID().Hex(), Actor: actor, At: time.Now()}
+}
+
+// EventID implements Event
+func (m Metadata) EventID() string {
+	return m.ID
+}
+
+// OccurredAt implements Event
+func (m Metadata) OccurredAt() time.Time {
+	return m.At
+}
+
+// TaskAggregate returns the aggregate ID of a task's events
+func TaskAggregate(taskID primitive.ObjectID) string {
+	return "task:" + taskID.Hex()
+}
+
+// snapshot copies a task, so that events are not affected by later changes to it
+func snapshot(task *models.Task) *models.Task {
+	copied := &models.Task{}
+	if data, err := bson.Marshal(task); err == nil && bson.Unmarshal(data, copied) == nil {
+		return copied
+	}
+	shallow := *task
+	return &shallow
+}
+
+// TaskCreated is published when a task is created
+type TaskCreated struct {
+	Metadata `bson:",inline"`
+	Task     *models.Task `json:"task" bson:"task"`
+}
+
+// NewTaskCreated creates the event of a task created by actor
+func NewTaskCreated(actor string, task *models.Task) *TaskCreated {
+	return &TaskCreated{Metadata: newMetadata(actor), Task: snapshot(task)}
+}
+
+func (e *TaskCreated) EventName() string   { return models.EventTaskCreated }
+func (e *TaskCreated) AggregateID() string { return TaskAggregate(e.Task.ID) }
+
+// Changes returns the fields set on the new task
+func (e *TaskCreated) Changes() []models.FieldChange {
+	return models.DiffTasks(nil, e.Task)
+}
+
+// TaskUpdated is published when fields of a task change
+type TaskUpdated struct {
+	Metadata `bson:",inline"`
+	// Task after the change
+	Task    *models.Task         `json:"task" bson:"task"`
+	Changes []models.FieldChange `json:"changes" bson:"changes"`
+}
+
+// NewTaskUpdated creates the event of a change from before to after, or returns nil if
+// no tracked field changed
+func NewTaskUpdated(actor string, before, after *models.Task) *TaskUpdated {
+	changes := models.DiffTasks(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	return &TaskUpdated{Metadata: newMetadata(actor), Task: snapshot(after), Changes: changes}
+}
+
+func (e *TaskUpdated) EventName() string   { return models.EventTaskUpdated }
+func (e *TaskUpdated) AggregateID() string { return TaskAggregate(e.Task.ID) }
+
+// TaskDeleted is published when a task is moved to the trash
+type TaskDeleted struct {
+	Metadata `bson:",inline"`
+	Task     *models.Task `json:"task" bson:"task"`
+}
+
+// NewTaskDeleted creates the event of a task moved to the trash
+func NewTaskDeleted(actor string, task *models.Task) *TaskDeleted {
+	return &TaskDeleted{Metadata: newMetadata(actor), Task: snapshot(task)}
+}
+
+func (e *TaskDeleted) EventName() string   { return models.EventTaskDeleted }
+func (e *TaskDeleted) AggregateID() string { return TaskAggregate(e.Task.ID) }
+
+// TaskRestored is published when a task is restored from the trash
+type TaskRestored struct {
+	Metadata `bson:",inline"`
+	Task     *models.Task `json:"task" bson:"task"`
+}
+
+// NewTaskRestored creates the event of a task restored from the trash
+func NewTaskRestored(actor string, task *models.Task) *TaskRestored {
+	return &TaskRestored{Metadata: newMetadata(actor), Task: snapshot(task)}
+}
+
+func (e *TaskRestored) EventName() string   { return models.EventTaskRestored }
+func (e *TaskRestored) AggregateID() string { return TaskAggregate(e.Task.ID) }
+
+// TaskPurged is published when a trashed task is permanently deleted
+type TaskPurged struct {
+	Metadata `bson:",inline"`
+	TaskID   primitive.ObjectID `json:"task_id" bson:"task_id"`
+}
+
+// NewTaskPurged creates the event of a task permanently deleted
+func NewTaskPurged(actor string, taskID primitive.ObjectID) *TaskPurged {
+	return &TaskPurged{Metadata: newMetadata(actor), TaskID: taskID}
+}
+
+func (e *TaskPurged) EventName() string   { return models.EventTaskPurged }
+func (e *TaskPurged) AggregateID() string { return TaskAggregate(e.TaskID) }
+
+// TaskCommented is published when a comment is added to a task
+type TaskCommented struct {
+	Metadata `bson:",inline"`
+	Task     *models.Task    `json:"task" bson:"task"`
+	Comment  *models.Comment `json:"comment" bson:"comment"`
+}
+
+// NewTaskCommented creates the event of a comment added to a task. The event occurred
+// when the comment was created, which may predate it for imported comments.
+func NewTaskCommented(task *models.Task, comment *models.Comment) *TaskCommented {
+	metadata := newMetadata(comment.Author)
+	metadata.At = comment.CreatedAt
+	return &TaskCommented{Metadata: metadata, Task: snapshot(task), Comment: comment}
+}
+
+func (e *TaskCommented) EventName() string   { return models.EventTaskCommented }
+func (e *TaskCommented) AggregateID() string { return TaskAggregate(e.Task.ID) }
+
+// UserRegistered is published when a user account is created
+type UserRegistered struct {
+	Metadata `bson:",inline"`
+	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username string             `json:"username" bson:"username"`
+	Role     string             `json:"role" bson:"role"`
+}
+
+// NewUserRegistered creates the event of a user that registered
+func NewUserRegistered(user *models.User) *UserRegistered {
+	return &UserRegistered{Metadata: newMetadata(user.Username), UserID: user.ID, Username: user.Username, Role: user.Role}
+}
+
+func (e *UserRegistered) EventName() string   { return UserRegisteredEvent }
+func (e *UserRegistered) AggregateID() string { return "user:" + e.UserID.Hex() }
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Sink receives the events relayed from the outbox. Events may be delivered more than
+// once, so sinks identify them by their ID.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// HTTPSink posts events as JSON to a URL, with the event ID in the Idempotency-Key
+// header. Any response other than 2xx fails the delivery.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink posting to the URL
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// httpEnvelope is the body posted by HTTPSink
+type httpEnvelope struct {
+	ID          string    `json:"id"`
+	Event       string    `json:"event"`
+	AggregateID string    `json:"aggregate_id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	Data        Event     `json:"data"`
+}
+
+// Publish implements Sink
+func (s *HTTPSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(httpEnvelope{
+		ID:          event.EventID(),
+		Event:       event.EventName(),
+		AggregateID: event.AggregateID(),
+		OccurredAt:  event.OccurredAt(),
+		Data:        event,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.EventID())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineLength is the longest content line allowed, in octets without the line break
+const maxLineLength = 75
+
+// textEscaper escapes TEXT property values
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// Writer writes iCalendar (RFC 5545) content lines, folding long lines. The first
+// error stops all further writes and is returned by Err.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter creates a writer of iCalendar content to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Begin starts a component, e.g. VCALENDAR or VEVENT
+func (w *Writer) Begin(component string) {
+	w.Line("BEGIN", component)
+}
+
+// End ends a component
+func (w *Writer) End(component string) {
+	w.Line("END", component)
+}
+
+// Text writes a property with a TEXT value, escaping it
+func (w *Writer) Text(name, value string) {
+	w.Line(name, Escape(value))
+}
+
+// Line writes a property with a value that is already in iCalendar form. The name may
+// carry parameters, e.g. DTSTART;VALUE=DATE.
+func (w *Writer) Line(name, value string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = io.WriteString(w.w, fold(name+":"+value))
+}
+
+// Err returns the first error that occurred while writing
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Escape escapes text for use as a TEXT value
+func Escape(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// DateTime formats a time as a UTC DATE-TIME value
+func DateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Date formats the UTC date of a time as a DATE value
+func Date(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+// fold breaks a content line into lines of at most maxLineLength octets, without
+// splitting characters, and ends it with CRLF. Continuation lines start with a space.
+func fold(line string) string {
+	var b strings.Builder
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the length of continuation lines
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubWebPrefix starts the web URLs of GitHub issues
+const githubWebPrefix = "https://github.com/"
+
+// githubIssue is an issue as returned by the REST API (GET /repos/{owner}/{repo}/issues)
+// or by gh issue list --json. The two differ in the case of some keys and in comments,
+// which the API only counts.
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	URL     string `json:"url"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Assignees []githubUser `json:"assignees"`
+	// Milestone due dates are the closest GitHub has to due dates
+	Milestone *struct {
+		DueOn    *time.Time `json:"due_on"`
+		DueOnCLI *time.Time `json:"dueOn"`
+	} `json:"milestone"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	CreatedAtCLI *time.Time      `json:"createdAt"`
+	Comments     json.RawMessage `json:"comments"`
+	// Set on pull requests, which the API lists along with issues
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubComment struct {
+	Author       githubUser `json:"author"`
+	User         githubUser `json:"user"`
+	Body         string     `json:"body"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	CreatedAtAPI time.Time  `json:"created_at"`
+}
+
+// parseGitHub reads a JSON array of GitHub issues. Items are in the issue's state, open
+// or closed, and assigned to its first assignee. Pull requests are skipped.
+func parseGitHub(r io.Reader) ([]Item, error) {
+	var issues []githubIssue
+	if err := json.NewDecoder(r).Decode(&issues); err != nil {
+		return nil, fmt.Errorf("invalid GitHub issues export: %w", err)
+	}
+
+	var items []Item
+	for i, issue := range issues {
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" {
+			continue
+		}
+
+		item := Item{
+			Row:         i + 1,
+			Title:       issue.Title,
+			Description: issue.Body,
+			Status:      strings.ToLower(issue.State),
+		}
+
+		// Identify the issue by its web URL, which names the repository as well, e.g.
+		// github:owner/repo/issues/12. The API's url field points at the API instead.
+		url := issue.HTMLURL
+		if url == "" && strings.HasPrefix(issue.URL, githubWebPrefix) {
+			url = issue.URL
+		}
+		if url != "" {
+			item.ExternalID = "github:" + strings.TrimPrefix(url, githubWebPrefix)
+		} else {
+			item.ExternalID = "github:" + strconv.Itoa(issue.Number)
+		}
+
+		if len(issue.Assignees) > 0 {
+			item.Assignee = issue.Assignees[0].Login
+		}
+		for _, label := range issue.Labels {
+			item.Labels = append(item.Labels, label.Name)
+		}
+		if issue.Milestone != nil {
+			item.DueAt = issue.Milestone.DueOn
+			if item.DueAt == nil {
+				item.DueAt = issue.Milestone.DueOnCLI
+			}
+		}
+		if issue.CreatedAt != nil {
+			item.CreatedAt = *issue.CreatedAt
+		} else if issue.CreatedAtCLI != nil {
+			item.CreatedAt = *issue.CreatedAtCLI
+		}
+
+		// The API gives a count instead of comments
+		var comments []githubComment
+		if err := json.Unmarshal(issue.Comments, &comments); err == nil {
+			for _, comment := range comments {
+				author, created := comment.Author.Login, comment.CreatedAt
+				if author == "" {
+					author = comment.User.Login
+				}
+				if created.IsZero() {
+					created = comment.CreatedAtAPI
+				}
+				item.Comments = append(item.Comments, Comment{Author: author, Body: comment.Body, CreatedAt: created})
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,119 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Supported sources
+const (
+	SourceTrello = "trello"
+	SourceJira   = "jira"
+	SourceGitHub = "github"
+)
+
+// parsers reads the export file of each source
+var parsers = map[string]func(io.Reader) ([]Item, error){
+	SourceTrello: parseTrello,
+	SourceJira:   parseJira,
+	SourceGitHub: parseGitHub,
+}
+
+// Item is a task read from an export file. Statuses and users are named as in the
+// source; a Mapping translates them.
+type Item struct {
+	// Position of the item in the file, for reporting: the line of CSV files or the
+	// 1-based index of the item otherwise
+	Row int
+	// Identifies the item across imports, prefixed with its source, e.g. jira:PROJ-123
+	ExternalID  string
+	Title       string
+	Description string
+	// Trello list, Jira status or GitHub issue state
+	Status   string
+	Assignee string
+	Labels   []string
+	DueAt    *time.Time
+	// When the item was created in the source; zero if unknown
+	CreatedAt time.Time
+	Comments  []Comment
+}
+
+// Comment is a comment on an item
+type Comment struct {
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// Mapping translates the statuses and users of a source into Taskify's
+type Mapping struct {
+	// Source status, list or state names to Taskify statuses. Unmapped statuses start
+	// in the workflow's initial status.
+	Statuses map[string]string `json:"statuses"`
+	// Source user names to Taskify usernames. Unmapped assignees are dropped, and
+	// comments of unmapped authors are attributed to the importing user.
+	Users map[string]string `json:"users"`
+	// Project the imported tasks are added to
+	Project string `json:"project"`
+}
+
+// Sources returns the names of the supported sources
+func Sources() []string {
+	sources := make([]string, 0, len(parsers))
+	for source := range parsers {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Parse reads the items of an export file of the given source
+func Parse(source string, r io.Reader) ([]Item, error) {
+	parse, ok := parsers[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q, expected one of: %s", source, strings.Join(Sources(), ", "))
+	}
+	return parse(r)
+}
+
+// LoadMapping reads a JSON mapping file
+func LoadMapping(r io.Reader) (*Mapping, error) {
+	var mapping Mapping
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("invalid mapping file: %w", err)
+	}
+	return &mapping, nil
+}
+
+// Status returns the Taskify status of a source status, or "" if it is not mapped.
+// Names are matched exactly first, then ignoring case.
+func (m *Mapping) Status(name string) string {
+	return lookup(m.Statuses, name)
+}
+
+// User returns the Taskify username of a source user, or "" if it is not mapped
+func (m *Mapping) User(name string) string {
+	return lookup(m.Users, name)
+}
+
+func lookup(values map[string]string, name string) string {
+	if name == "" {
+		return ""
+	}
+	if value, ok := values[name]; ok {
+		return value
+	}
+	for key, value := range values {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package importers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jiraDateLayouts are the date formats of Jira CSV exports, which follow the instance's
+// date settings
+var jiraDateLayouts = []string{
+	"02/Jan/06 3:04 PM",
+	"02/Jan/2006 3:04 PM",
+	"02/Jan/06",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+}
+
+// parseJira reads a Jira CSV export (Filters > Export > CSV). Columns are matched by
+// name ignoring case. Jira repeats the Labels and Comment columns once per value;
+// comments are given as "date;author;body".
+func parseJira(r io.Reader) ([]Item, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("Jira export is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Jira CSV export: %w", err)
+	}
+	columns := map[string][]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = append(columns[name], i)
+	}
+	if len(columns["summary"]) == 0 || len(columns["issue key"]) == 0 {
+		return nil, fmt.Errorf("Jira CSV export must have Summary and Issue key columns")
+	}
+
+	var items []Item
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Jira CSV export: %w", err)
+		}
+
+		// values returns the non-empty values of all columns with the given name
+		values := func(name string) []string {
+			var found []string
+			for _, i := range columns[name] {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					found = append(found, strings.TrimSpace(record[i]))
+				}
+			}
+			return found
+		}
+		value := func(name string) string {
+			if found := values(name); len(found) > 0 {
+				return found[0]
+			}
+			return ""
+		}
+
+		line, _ := reader.FieldPos(0)
+		item := Item{
+			Row:         line,
+			ExternalID:  "jira:" + value("issue key"),
+			Title:       value("summary"),
+			Description: value("description"),
+			Status:      value("status"),
+			Assignee:    value("assignee"),
+			Labels:      values("labels"),
+		}
+		if created, ok := parseJiraDate(value("created")); ok {
+			item.CreatedAt = created
+		}
+		if due, ok := parseJiraDate(value("due date")); ok {
+			item.DueAt = &due
+		}
+		for _, text := range values("comment") {
+			item.Comments = append(item.Comments, parseJiraComment(text))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseJiraComment splits a "date;author;body" comment. Comments in another form are
+// kept whole as the body.
+func parseJiraComment(text string) Comment {
+	parts := strings.SplitN(text, ";", 3)
+	if len(parts) == 3 {
+		if created, ok := parseJiraDate(parts[0]); ok {
+			return Comment{Author: parts[1], Body: parts[2], CreatedAt: created}
+		}
+	}
+	return Comment{Body: text}
+}
+
+func parseJiraDate(value string) (time.Time, bool) {
+	for _, layout := range jiraDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,118 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trelloBoard is the part of a Trello board export (Menu > Print, export and share >
+// Export as JSON) that is imported
+type trelloBoard struct {
+	Lists []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"lists"`
+	Members []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"members"`
+	Cards []struct {
+		ID        string   `json:"id"`
+		ShortLink string   `json:"shortLink"`
+		Name      string   `json:"name"`
+		Desc      string   `json:"desc"`
+		IDList    string   `json:"idList"`
+		IDMembers []string `json:"idMembers"`
+		Labels    []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+		Due *time.Time `json:"due"`
+	} `json:"cards"`
+	Actions []struct {
+		Type string    `json:"type"`
+		Date time.Time `json:"date"`
+		Data struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+		MemberCreator struct {
+			Username string `json:"username"`
+		} `json:"memberCreator"`
+	} `json:"actions"`
+}
+
+// parseTrello reads a Trello board export. Cards become items in the status of their
+// list, assigned to their first member.
+func parseTrello(r io.Reader) ([]Item, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, fmt.Errorf("invalid Trello board export: %w", err)
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+	}
+	members := make(map[string]string, len(board.Members))
+	for _, member := range board.Members {
+		members[member.ID] = member.Username
+	}
+
+	comments := map[string][]Comment{}
+	for _, action := range board.Actions {
+		if action.Type != "commentCard" {
+			continue
+		}
+		comments[action.Data.Card.ID] = append(comments[action.Data.Card.ID], Comment{
+			Author:    action.MemberCreator.Username,
+			Body:      action.Data.Text,
+			CreatedAt: action.Date,
+		})
+	}
+
+	items := make([]Item, len(board.Cards))
+	for i, card := range board.Cards {
+		item := Item{
+			Row:         i + 1,
+			ExternalID:  "trello:" + card.ID,
+			Title:       card.Name,
+			Description: card.Desc,
+			Status:      lists[card.IDList],
+			DueAt:       card.Due,
+			Comments:    comments[card.ID],
+		}
+		if card.ShortLink != "" {
+			item.ExternalID = "trello:" + card.ShortLink
+		}
+		if len(card.IDMembers) > 0 {
+			item.Assignee = members[card.IDMembers[0]]
+		}
+		for _, label := range card.Labels {
+			// Labels may have only a color
+			if label.Name != "" {
+				item.Labels = append(item.Labels, label.Name)
+			} else if label.Color != "" {
+				item.Labels = append(item.Labels, label.Color)
+			}
+		}
+		// Trello IDs are object IDs, which carry their creation time
+		if id, err := primitive.ObjectIDFromHex(card.ID); err == nil {
+			item.CreatedAt = id.Timestamp()
+		}
+
+		// Actions are exported newest first
+		sort.SliceStable(item.Comments, func(a, b int) bool {
+			return item.Comments[a].CreatedAt.Before(item.Comments[b].CreatedAt)
+		})
+		items[i] = item
+	}
+	return items, nil
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
@@ -10,9 +16,10 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"taskify/config"
-	_ "taskify/docs" // Import swagger docs
+	"taskify/docs"
 	"taskify/middleware"
 	"taskify/routes"
+	"taskify/services"
 	"taskify/utils"
 )
 
@@ -32,16 +39,28 @@ func main() {
 	utils.InitValidator()
 
 	// Initialize configuration
-	config.LoadConfig()
+	if err := config.LoadConfig(); err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
+	config.LoadWorkflow()
 	config.ConnectDatabase()
+	config.EnsureIndexes()
+	config.ConnectStorage()
+	config.ConnectRealtime()
+	services.SubscribeEventHandlers()
+	services.ConfigureOutbox()
 
-	// Initialize Gin
-	r := gin.Default()
+	// Initialize Gin, with tokens passed in query strings kept out of the access log
+	r := gin.New()
+	r.Use(middleware.Logger(), gin.Recovery())
 
 	// Global middleware
 	r.Use(middleware.ErrorHandler()) // Register error handler first
 
-	// Swagger documentation endpoint
+	// Swagger documentation endpoint, with status enums taken from the default workflow
+	if err := config.ApplyWorkflowToSwagger(docs.SwaggerInfo); err != nil {
+		log.Fatal("Failed to apply workflow to Swagger documentation:", err)
+	}
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Initialize Casbin enforcer
@@ -53,7 +72,49 @@ func main() {
 	// Register routes
 	routes.RegisterRoutes(r, enforcer)
 
+	// Background jobs and the server stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background jobs
+	if days := config.AppConfig.TrashRetentionDays; days > 0 {
+		go services.RunTrashRetention(ctx, time.Duration(days)*24*time.Hour, time.Hour)
+	}
+	go services.RunRecurrenceScheduler(ctx, time.Minute)
+	go services.RunWebhookDispatcher(ctx, 5*time.Second)
+	go services.RunOutboxRelay(ctx, time.Second)
+	go config.Realtime.Run(ctx)
+	go func() {
+		if updated, err := services.BackfillCommentText(ctx); err != nil {
+			log.Printf("Error: comment search backfill failed: %v", err)
+		} else if updated > 0 {
+			log.Printf("Indexed comments of %d task(s) for search", updated)
+		}
+	}()
+
 	// Start server
-	serverAddr := fmt.Sprintf("%s:%s", config.AppConfig.ServerAddress, config.AppConfig.ServerPort)
-	r.Run(serverAddr)
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", config.AppConfig.ServerAddress, config.AppConfig.ServerPort),
+		Handler: r,
+	}
+	// Event streams never finish on their own, so end them before waiting for requests
+	server.RegisterOnShutdown(services.TaskStream.Close)
+	server.RegisterOnShutdown(config.Realtime.Close)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error: server shutdown: %v", err)
+	}
+	if err := services.Events.Close(shutdownCtx); err != nil {
+		log.Printf("Error: %v", err)
+	}
 }
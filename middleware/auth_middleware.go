@@ -1,11 +1,16 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/config"
+	"taskify/models"
 )
 
 var jwtSecret = []byte("your-secret-key") // TODO: Move to environment variables
@@ -13,6 +18,11 @@ var jwtSecret = []byte("your-secret-key") // TODO: Move to environment variables
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		// Browsers cannot set headers on WebSocket handshakes, so these may pass the token
+		// as a query parameter
+		if token := c.Query("access_token"); authHeader == "" && token != "" && isWebSocketUpgrade(c) {
+			authHeader = "Bearer " + token
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
@@ -47,6 +57,35 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// BasicAuthMiddleware authenticates users by their username and password with HTTP Basic
+// authentication, for clients such as CalDAV clients that cannot obtain bearer tokens
+func BasicAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			basicAuthChallenge(c)
+			return
+		}
+
+		var user models.User
+		err := config.DB.Collection("users").FindOne(context.Background(), bson.M{"username": username}).Decode(&user)
+		if err != nil || !user.CheckPassword(password) {
+			basicAuthChallenge(c)
+			return
+		}
+
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// basicAuthChallenge rejects the request, asking the client for credentials
+func basicAuthChallenge(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="Taskify", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
 // GenerateToken creates a new JWT token for a user
 func GenerateToken(username, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
@@ -56,3 +95,8 @@ func GenerateToken(username, role string) (string, error) {
 
 	return token.SignedString(jwtSecret)
 }
+
+// isWebSocketUpgrade reports whether the request is a WebSocket handshake
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
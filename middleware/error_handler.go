@@ -13,21 +13,26 @@ func ErrorHandler() gin.HandlerFunc {
 		// Process request
 		c.Next()
 
-		// Check if there are any errors
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last().Err
-			appErr := errors.AsAppError(err)
+		// Check if there are any errors not rendered by an inner middleware yet
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			renderError(c)
+		}
+	}
+}
 
-			// Log error details (in production, you might want to use a proper logger)
-			log.Printf("Error: %v", appErr.Err)
+// renderError writes the last error of the request as the response
+func renderError(c *gin.Context) {
+	err := c.Errors.Last().Err
+	appErr := errors.AsAppError(err)
 
-			// Send error response
-			c.JSON(appErr.StatusCode, gin.H{
-				"error": appErr.Message,
-			})
+	// Log error details (in production, you might want to use a proper logger)
+	log.Printf("Error: %v", appErr.Err)
 
-			// Stop processing
-			c.Abort()
-		}
-	}
+	// Send error response
+	c.JSON(appErr.StatusCode, gin.H{
+		"error": appErr.Message,
+	})
+
+	// Stop processing
+	c.Abort()
 }
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	// idempotencyLockTTL bounds how long a request that never completes, e.g. because the
+	// server stopped, blocks retries with the same key
+	idempotencyLockTTL = time.Minute
+)
+
+// replayedHeaders are the response headers stored and replayed with the body
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Link"}
+
+// responseRecorder captures the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes POST, PATCH and DELETE requests carrying an Idempotency-Key
+// header safe to retry. The first response to a key is stored per user and replayed for
+// later requests with the same key; reusing a key for a different request is rejected
+// with 422. Server errors are not stored, so such requests can be retried.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		method := c.Request.Method
+		if key == "" || (method != http.MethodPost && method != http.MethodPatch && method != http.MethodDelete) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			abortWithError(c, errors.NewInvalidInput("Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLen)+" characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, errors.NewInvalidInput("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+
+		collection := config.DB.Collection("idempotency_keys")
+		ctx := context.Background()
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			ID:          models.IdempotencyKey{User: c.GetString("username"), Key: key},
+			RequestHash: hex.EncodeToString(hash.Sum(nil)),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyLockTTL),
+		}
+
+		// Claim the key, or replay the response of the request that claimed it first
+		if _, err := collection.InsertOne(ctx, record); err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				abortWithError(c, errors.NewDatabaseError(err))
+				return
+			}
+			replayResponse(c, collection, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Render errors now so the error response is stored too
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			renderError(c)
+		}
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if _, err := collection.DeleteOne(ctx, bson.M{"_id": record.ID}); err != nil {
+				log.Printf("Error: failed to release idempotency key %q: %v", key, err)
+			}
+			return
+		}
+
+		headers := map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		ttl := time.Duration(config.AppConfig.IdempotencyKeyTTLHours) * time.Hour
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": bson.M{
+			"completed":  true,
+			"status":     status,
+			"headers":    headers,
+			"body":       recorder.body.Bytes(),
+			"expires_at": time.Now().Add(ttl),
+		}})
+		if err != nil {
+			log.Printf("Error: failed to store response for idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// replayResponse answers a retried request with the stored response of the first one
+func replayResponse(c *gin.Context, collection *mongo.Collection, request models.IdempotencyRecord) {
+	var stored models.IdempotencyRecord
+	if err := collection.FindOne(context.Background(), bson.M{"_id": request.ID}).Decode(&stored); err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The first request failed and released the key in the meantime
+			abortWithError(c, errors.NewConflict("A request with this Idempotency-Key has just failed; retry it"))
+			return
+		}
+		abortWithError(c, errors.NewDatabaseError(err))
+		return
+	}
+
+	switch {
+	case stored.RequestHash != request.RequestHash:
+		abortWithError(c, errors.NewUnprocessableEntity("Idempotency-Key was already used for a different request"))
+	case !stored.Completed:
+		abortWithError(c, errors.NewConflict("A request with this Idempotency-Key is still being processed"))
+	default:
+		for name, value := range stored.Headers {
+			c.Header(name, value)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(stored.Status)
+		if len(stored.Body) > 0 {
+			_, _ = c.Writer.Write(stored.Body)
+		}
+		c.Abort()
+	}
+}
+
+// abortWithError stops the request with an error rendered by ErrorHandler
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
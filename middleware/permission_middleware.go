@@ -2,12 +2,14 @@ package middleware
 
 import (
 	"net/http"
-	"path/filepath"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
 )
 
+const enforcerKey = "enforcer"
+
 func PermissionMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user role from context (set by AuthMiddleware)
@@ -18,8 +20,8 @@ func PermissionMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 			return
 		}
 
-		// Get the request path and method
-		path := filepath.Clean(c.Request.URL.Path)
+		// Get the route pattern relative to the API prefix (e.g. /tasks/:id) and method
+		path := strings.TrimPrefix(c.FullPath(), "/api/v1")
 		method := c.Request.Method
 
 		// Check if the user has permission
@@ -36,6 +38,29 @@ func PermissionMiddleware(e *casbin.Enforcer) gin.HandlerFunc {
 			return
 		}
 
+		// Keep the enforcer for handlers that check further actions themselves
+		c.Set(enforcerKey, e)
 		c.Next()
 	}
 }
+
+// WithEnforcer makes the enforcer available to Authorize without checking the route
+// itself, for handlers serving protocols whose requests do not map to API routes
+func WithEnforcer(e *casbin.Enforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(enforcerKey, e)
+		c.Next()
+	}
+}
+
+// Authorize reports whether the current user's role may perform the given method on the
+// given route pattern (relative to /api/v1), as checked by PermissionMiddleware.
+// Handlers performing several actions in one request, such as bulk operations, use it to
+// check each action.
+func Authorize(c *gin.Context, path, method string) (bool, error) {
+	e, ok := c.MustGet(enforcerKey).(*casbin.Enforcer)
+	if !ok {
+		return false, nil
+	}
+	return e.Enforce(c.GetString("role"), path, method)
+}
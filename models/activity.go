@@ -0,0 +1,117 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Activity actions recorded in a task's history
+const (
+	ActivityCreated   = "created"
+	ActivityUpdated   = "updated"
+	ActivityDeleted   = "deleted"
+	ActivityRestored  = "restored"
+	ActivityPurged    = "purged"
+	ActivityCommented = "commented"
+	ActivityAssigned  = "assigned"
+)
+
+// FieldChange describes the value of a single task field before and after a change
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// Activity is an immutable entry in a task's history
+type Activity struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	Action    string             `json:"action" bson:"action"`
+	Actor     string             `json:"actor" bson:"actor"`
+	Changes   []FieldChange      `json:"changes,omitempty" bson:"changes,omitempty"`
+	CommentID primitive.ObjectID `json:"comment_id,omitempty" bson:"comment_id,omitempty"`
+	// Domain event the entry was recorded for
+	EventID   string    `json:"-" bson:"event_id,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// NewActivity creates a new activity entry for a task
+func NewActivity(taskID primitive.ObjectID, action, actor string, changes []FieldChange) *Activity {
+	return &Activity{
+		TaskID:    taskID,
+		Action:    action,
+		Actor:     actor,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+}
+
+// untrackedTaskFields are bookkeeping fields that are not reported in diffs
+var untrackedTaskFields = map[string]bool{
+	"_id":             true,
+	"created_at":      true,
+	"updated_at":      true,
+	"deleted_at":      true,
+	"deleted_by":      true,
+	"comment_text":    true,
+	"calendar_object": true,
+	"priority_rank":   true,
+	"version":         true,
+}
+
+// DiffTasks returns the field-level changes between two versions of a task.
+// A nil before reports every non-empty field of after as newly set.
+func DiffTasks(before, after *Task) []FieldChange {
+	if before == nil {
+		before = &Task{}
+	}
+	beforeValue := reflect.ValueOf(before).Elem()
+	afterValue := reflect.ValueOf(after).Elem()
+	taskType := afterValue.Type()
+
+	var changes []FieldChange
+	for i := 0; i < taskType.NumField(); i++ {
+		field := strings.SplitN(taskType.Field(i).Tag.Get("bson"), ",", 2)[0]
+		if field == "" || field == "-" || untrackedTaskFields[field] {
+			continue
+		}
+
+		oldValue := beforeValue.Field(i).Interface()
+		newValue := afterValue.Field(i).Interface()
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Before: oldValue, After: newValue})
+	}
+	return changes
+}
+
+// swagger:model Activity
+type ActivityResponse struct {
+	ID        string                `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1c"`
+	TaskID    string                `json:"task_id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Action    string                `json:"action" example:"updated" enum:"created,updated,deleted,restored,purged,commented,assigned"`
+	Actor     string                `json:"actor" example:"johndoe"`
+	Changes   []FieldChangeResponse `json:"changes,omitempty"`
+	CommentID string                `json:"comment_id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1d"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// swagger:model ActivityList
+type ActivityListResponse struct {
+	Data  []ActivityResponse `json:"data"`
+	Page  int                `json:"page" example:"1"`
+	Limit int                `json:"limit" example:"10"`
+	Total int64              `json:"total" example:"42"`
+}
+
+// swagger:model FieldChange
+type FieldChangeResponse struct {
+	Field  string `json:"field" example:"status"`
+	Before string `json:"before" example:"pending"`
+	After  string `json:"after" example:"in_progress"`
+}
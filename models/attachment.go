@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment represents a file attached to a task
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	FileName    string             `json:"file_name" bson:"file_name"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	StorageKey  string             `json:"-" bson:"storage_key"` // Key of the blob in the configured BlobStore
+	UploadedBy  string             `json:"uploaded_by" bson:"uploaded_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// NewAttachment creates a new attachment for a task with a freshly allocated ID
+func NewAttachment(taskID primitive.ObjectID, fileName, contentType string, size int64, uploadedBy string) *Attachment {
+	id := primitive.NewObjectID()
+	return &Attachment{
+		ID:          id,
+		TaskID:      taskID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  "tasks/" + taskID.Hex() + "/" + id.Hex(),
+		UploadedBy:  uploadedBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// swagger:model Attachment
+type AttachmentResponse struct {
+	ID          string    `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1b"`
+	TaskID      string    `json:"task_id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	FileName    string    `json:"file_name" example:"invoice.pdf"`
+	ContentType string    `json:"content_type" example:"application/pdf"`
+	Size        int64     `json:"size" example:"52431"`
+	UploadedBy  string    `json:"uploaded_by" example:"johndoe"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Bulk operation kinds
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// MaxBulkOperations is the largest number of tasks a single bulk request may change
+const MaxBulkOperations = 500
+
+// BulkOperationDTO is a single operation of a bulk request
+type BulkOperationDTO struct {
+	Op string `json:"op" binding:"required,oneof=create update delete" example:"update"`
+	// ID of the task to update or delete
+	ID string `json:"id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	// Optional ETag the update or delete is based on, as in the If-Match header
+	IfMatch string `json:"if_match,omitempty" example:"\"3\""`
+	// Task to create
+	Task *CreateTaskDTO `json:"task,omitempty"`
+	// JSON Merge Patch to apply to the task to update
+	Patch json.RawMessage `json:"patch,omitempty" swaggertype:"object"`
+}
+
+// BulkRequestDTO is a list of task operations. In atomic mode either all operations
+// succeed or none are applied.
+type BulkRequestDTO struct {
+	Atomic     bool               `json:"atomic" example:"false"`
+	Operations []BulkOperationDTO `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+// BulkUpdateDTO applies the same merge patch to every task matching a filter expression
+type BulkUpdateDTO struct {
+	Filter string `json:"filter" binding:"required" example:"labels = sprint-12"`
+	// JSON Merge Patch to apply to each matching task
+	Set    json.RawMessage `json:"set" binding:"required" swaggertype:"object"`
+	Atomic bool            `json:"atomic" example:"false"`
+}
+
+// BulkResult is the outcome of one operation of a bulk request
+type BulkResult struct {
+	Index int    `json:"index" example:"0"`
+	Op    string `json:"op" example:"update"`
+	ID    string `json:"id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	// HTTP status the operation would have had as a single request
+	Status int    `json:"status" example:"200"`
+	Error  string `json:"error,omitempty"`
+	Task   *Task  `json:"task,omitempty"`
+}
+
+// BulkReport reports the outcome of every operation of a bulk request
+type BulkReport struct {
+	Atomic bool `json:"atomic"`
+	// Whether the changes were applied; false when an atomic request was rolled back
+	Committed bool         `json:"committed"`
+	Succeeded int          `json:"succeeded" example:"2"`
+	Failed    int          `json:"failed" example:"0"`
+	Results   []BulkResult `json:"results"`
+}
+
+// swagger:model BulkResult
+type BulkResultResponse struct {
+	Index  int           `json:"index" example:"0"`
+	Op     string        `json:"op" example:"update" enum:"create,update,delete"`
+	ID     string        `json:"id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Status int           `json:"status" example:"200"`
+	Error  string        `json:"error,omitempty" example:"Task not found"`
+	Task   *TaskResponse `json:"task,omitempty"`
+}
+
+// swagger:model BulkReport
+type BulkReportResponse struct {
+	Atomic    bool                 `json:"atomic" example:"true"`
+	Committed bool                 `json:"committed" example:"true"`
+	Succeeded int                  `json:"succeeded" example:"2"`
+	Failed    int                  `json:"failed" example:"0"`
+	Results   []BulkResultResponse `json:"results"`
+}
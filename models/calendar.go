@@ -0,0 +1,26 @@
+package models
+
+// CalendarObject records how a task created by a CalDAV client is addressed by it. Tasks
+// created through the API are addressed by their ID instead.
+type CalendarObject struct {
+	// Resource name the client stored the task under, without the .ics extension
+	Name string `bson:"name"`
+	// UID of the client's VTODO
+	UID string `bson:"uid"`
+}
+
+// CalendarName returns the name of the task's CalDAV resource, without the .ics extension
+func (t *Task) CalendarName() string {
+	if t.CalendarObject != nil {
+		return t.CalendarObject.Name
+	}
+	return t.ID.Hex()
+}
+
+// CalendarUID returns the UID of the task in calendars
+func (t *Task) CalendarUID() string {
+	if t.CalendarObject != nil {
+		return t.CalendarObject.UID
+	}
+	return t.ID.Hex() + "@taskify"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateCommentDTO represents the data needed to comment on a task
+type CreateCommentDTO struct {
+	Body string `json:"body" binding:"required,min=1,max=2000" example:"Blocked until the API keys arrive"`
+}
+
+// Comment represents a comment on a task
+type Comment struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	Author    string             `json:"author" bson:"author"`
+	Body      string             `json:"body" bson:"body"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// NewComment creates a new comment on a task
+func NewComment(taskID primitive.ObjectID, author, body string) *Comment {
+	return &Comment{
+		TaskID:    taskID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+// swagger:model Comment
+type CommentResponse struct {
+	ID        string    `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1d"`
+	TaskID    string    `json:"task_id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Author    string    `json:"author" example:"johndoe"`
+	Body      string    `json:"body" example:"Blocked until the API keys arrive"`
+	CreatedAt time.Time `json:"created_at"`
+}
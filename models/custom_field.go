@@ -0,0 +1,188 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Custom field types
+const (
+	FieldTypeText   = "text"
+	FieldTypeNumber = "number"
+	FieldTypeDate   = "date"
+	FieldTypeEnum   = "enum"
+	FieldTypeUser   = "user"
+)
+
+// customFieldKeyPattern restricts keys to names that are safe in query parameters and document paths
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CustomFieldDTO represents the data needed to define a custom field
+type CustomFieldDTO struct {
+	Key       string   `json:"key" binding:"required" example:"story_points"`
+	Name      string   `json:"name" binding:"required,min=1,max=100" example:"Story points"`
+	Type      string   `json:"type" binding:"required,oneof=text number date enum user" example:"number"`
+	Required  bool     `json:"required,omitempty" example:"false"`
+	Options   []string `json:"options,omitempty" binding:"omitempty,dive,min=1,max=100" example:"production"`
+	Min       *float64 `json:"min,omitempty" example:"0"`
+	Max       *float64 `json:"max,omitempty" example:"100"`
+	MaxLength int      `json:"max_length,omitempty" binding:"omitempty,min=1" example:"200"`
+}
+
+// UpdateCustomFieldDTO represents the changes allowed to an existing custom field.
+// The key and type of a field cannot be changed, so stored values always keep their meaning.
+type UpdateCustomFieldDTO struct {
+	Name      string   `json:"name" binding:"required,min=1,max=100" example:"Story points"`
+	Required  bool     `json:"required,omitempty" example:"false"`
+	Options   []string `json:"options,omitempty" binding:"omitempty,dive,min=1,max=100" example:"production"`
+	Min       *float64 `json:"min,omitempty" example:"0"`
+	Max       *float64 `json:"max,omitempty" example:"100"`
+	MaxLength int      `json:"max_length,omitempty" binding:"omitempty,min=1" example:"200"`
+}
+
+// CustomField defines an admin-managed field that tasks can carry in CustomFields
+type CustomField struct {
+	Key       string    `json:"key" bson:"_id"`
+	Name      string    `json:"name" bson:"name"`
+	Type      string    `json:"type" bson:"type"`
+	Required  bool      `json:"required" bson:"required"`
+	Options   []string  `json:"options,omitempty" bson:"options,omitempty"`
+	Min       *float64  `json:"min,omitempty" bson:"min,omitempty"`
+	Max       *float64  `json:"max,omitempty" bson:"max,omitempty"`
+	MaxLength int       `json:"max_length,omitempty" bson:"max_length,omitempty"`
+	Archived  bool      `json:"archived" bson:"archived"` // Archived fields keep their stored values but can no longer be set
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// NewCustomField creates a custom field definition
+func NewCustomField(input CustomFieldDTO) (*CustomField, error) {
+	if !customFieldKeyPattern.MatchString(input.Key) {
+		return nil, fmt.Errorf("key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+	now := time.Now()
+	field := &CustomField{
+		Key:       input.Key,
+		Type:      input.Type,
+		CreatedAt: now,
+	}
+	if err := field.Update(UpdateCustomFieldDTO{
+		Name:      input.Name,
+		Required:  input.Required,
+		Options:   input.Options,
+		Min:       input.Min,
+		Max:       input.Max,
+		MaxLength: input.MaxLength,
+	}); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// Update changes the validation rules of the field. Rules only apply to values written
+// afterwards; values already stored on tasks are left untouched.
+func (f *CustomField) Update(input UpdateCustomFieldDTO) error {
+	if f.Type == FieldTypeEnum && len(input.Options) == 0 {
+		return fmt.Errorf("options are required for enum fields")
+	}
+	if f.Type != FieldTypeEnum && len(input.Options) > 0 {
+		return fmt.Errorf("options are only allowed for enum fields")
+	}
+	if f.Type != FieldTypeNumber && (input.Min != nil || input.Max != nil) {
+		return fmt.Errorf("min and max are only allowed for number fields")
+	}
+	if input.Min != nil && input.Max != nil && *input.Min > *input.Max {
+		return fmt.Errorf("min must not be greater than max")
+	}
+	if f.Type != FieldTypeText && input.MaxLength > 0 {
+		return fmt.Errorf("max_length is only allowed for text fields")
+	}
+
+	f.Name = input.Name
+	f.Required = input.Required
+	f.Options = input.Options
+	f.Min = input.Min
+	f.Max = input.Max
+	f.MaxLength = input.MaxLength
+	f.UpdatedAt = time.Now()
+	return nil
+}
+
+// Normalize validates a JSON-decoded value against the field definition and converts it
+// into the form stored on the task. User references are only checked for type here;
+// whether the user exists must be checked by the caller.
+func (f *CustomField) Normalize(value interface{}) (interface{}, error) {
+	switch f.Type {
+	case FieldTypeText, FieldTypeUser:
+		text, ok := value.(string)
+		if !ok || text == "" {
+			return nil, fmt.Errorf("custom field %s must be a non-empty string", f.Key)
+		}
+		if f.MaxLength > 0 && len([]rune(text)) > f.MaxLength {
+			return nil, fmt.Errorf("custom field %s must be at most %d characters", f.Key, f.MaxLength)
+		}
+		return text, nil
+
+	case FieldTypeNumber:
+		number, ok := value.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("custom field %s must be a number", f.Key)
+		}
+		if f.Min != nil && number < *f.Min {
+			return nil, fmt.Errorf("custom field %s must be at least %v", f.Key, *f.Min)
+		}
+		if f.Max != nil && number > *f.Max {
+			return nil, fmt.Errorf("custom field %s must be at most %v", f.Key, *f.Max)
+		}
+		return number, nil
+
+	case FieldTypeDate:
+		text, _ := value.(string)
+		date, err := ParseFieldDate(text)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %s must be a date (YYYY-MM-DD or RFC 3339)", f.Key)
+		}
+		return date, nil
+
+	case FieldTypeEnum:
+		text, _ := value.(string)
+		for _, option := range f.Options {
+			if text == option {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("custom field %s must be one of: %s", f.Key, strings.Join(f.Options, ", "))
+	}
+	return nil, fmt.Errorf("custom field %s has unknown type %s", f.Key, f.Type)
+}
+
+// ParseQueryValue converts a query string value into the stored form of the field, for filtering
+func (f *CustomField) ParseQueryValue(value string) (interface{}, error) {
+	switch f.Type {
+	case FieldTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %s must be filtered by a number", f.Key)
+		}
+		return number, nil
+	case FieldTypeDate:
+		date, err := ParseFieldDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %s must be filtered by a date (YYYY-MM-DD or RFC 3339)", f.Key)
+		}
+		return date, nil
+	}
+	return value, nil
+}
+
+// ParseFieldDate parses a date given either as YYYY-MM-DD or as an RFC 3339 timestamp
+func ParseFieldDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
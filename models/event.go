@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task events, as named in webhook subscriptions and event streams
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskDeleted   = "task.deleted"
+	EventTaskRestored  = "task.restored"
+	EventTaskPurged    = "task.purged"
+	EventTaskCommented = "task.commented"
+)
+
+// TaskEvent describes a change to a task, as sent to webhooks and event streams. The ID
+// is that of the domain event, so it identifies the event across redeliveries.
+type TaskEvent struct {
+	ID     string             `json:"id"`
+	Event  string             `json:"event"`
+	Actor  string             `json:"actor"`
+	TaskID primitive.ObjectID `json:"task_id"`
+	// Task after the change; absent once the task has been purged
+	Task      *Task              `json:"task,omitempty"`
+	Changes   []FieldChange      `json:"changes,omitempty"`
+	CommentID primitive.ObjectID `json:"comment_id,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+}
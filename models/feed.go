@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateFeedTokenDTO represents the data needed to create a calendar feed token
+type CreateFeedTokenDTO struct {
+	Name string `json:"name" binding:"required,min=1,max=100" example:"Outlook at work"`
+}
+
+// FeedToken grants read access to a user's calendar feed through a secret URL. Only a
+// hash of the secret is stored; deleting the token revokes the URL.
+type FeedToken struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	User       string             `json:"-" bson:"user"`
+	Name       string             `json:"name" bson:"name"`
+	SecretHash string             `json:"-" bson:"secret_hash"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+}
+
+// NewFeedToken creates a feed token for the user and returns it with its secret, which
+// cannot be recovered later
+func NewFeedToken(user, name string) (*FeedToken, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+
+	return &FeedToken{
+		User:       user,
+		Name:       name,
+		SecretHash: HashFeedSecret(encoded),
+		CreatedAt:  time.Now(),
+	}, encoded, nil
+}
+
+// HashFeedSecret returns the stored form of a feed token secret
+func HashFeedSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// swagger:model FeedToken
+type FeedTokenResponse struct {
+	ID         string     `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1e"`
+	Name       string     `json:"name" example:"Outlook at work"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// swagger:model CreatedFeedToken
+type CreatedFeedTokenResponse struct {
+	ID        string    `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1e"`
+	Name      string    `json:"name" example:"Outlook at work"`
+	CreatedAt time.Time `json:"created_at"`
+	// Secret feed URL; it is only returned once
+	URL string `json:"url" example:"https://taskify.example.com/api/v1/calendar/q8V0d3X1bS9kYvH2mZ7pLw4tR6cN5aE0uJ1iO3gF8hK/tasks.ics"`
+}
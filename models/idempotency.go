@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey identifies a request by the user who sent it and their Idempotency-Key
+type IdempotencyKey struct {
+	User string `bson:"user"`
+	Key  string `bson:"key"`
+}
+
+// IdempotencyRecord stores the response to a request sent with an Idempotency-Key so it
+// can be replayed when the request is retried
+type IdempotencyRecord struct {
+	ID IdempotencyKey `bson:"_id"`
+	// Hash of the method, path and body of the request
+	RequestHash string `bson:"request_hash"`
+	// False while the first request is still being processed
+	Completed bool              `bson:"completed"`
+	Status    int               `bson:"status,omitempty"`
+	Headers   map[string]string `bson:"headers,omitempty"`
+	Body      []byte            `bson:"body,omitempty"`
+	CreatedAt time.Time         `bson:"created_at"`
+	ExpiresAt time.Time         `bson:"expires_at"`
+}
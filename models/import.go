@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Import limits
+const (
+	// MaxImportRows is the largest number of rows a single import may contain
+	MaxImportRows = 5000
+	// ImportBatchSize is the number of tasks inserted at a time
+	ImportBatchSize = 500
+)
+
+// Outcomes of an imported row
+const (
+	ImportRowValid     = "valid"
+	ImportRowCreated   = "created"
+	ImportRowDuplicate = "duplicate"
+	ImportRowInvalid   = "invalid"
+)
+
+// ImportFields are the task fields source columns may be mapped to. Custom fields are
+// mapped as cf.<key>.
+var ImportFields = []string{
+	"external_id", "title", "description", "status", "priority", "assignee", "project", "parent_id", "due_at", "labels",
+}
+
+// ImportRow is a task read from one row of an import, after the column mapping is
+// applied. Values are kept as given so every row can be validated and reported.
+type ImportRow struct {
+	ExternalID  string   `json:"external_id" validate:"omitempty,max=100"`
+	Title       string   `json:"title" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"omitempty,max=500"`
+	Status      string   `json:"status" validate:"omitempty,max=50"`
+	Priority    string   `json:"priority" validate:"omitempty,oneof=low medium high urgent"`
+	Assignee    string   `json:"assignee"`
+	Project     string   `json:"project" validate:"omitempty,max=100"`
+	ParentID    string   `json:"parent_id" validate:"omitempty,mongodb"`
+	DueAt       string   `json:"due_at"`
+	Labels      []string `json:"labels" validate:"omitempty,max=20,dive,min=1,max=50"`
+	// Custom field values keyed by field key
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// TaskDTO converts the row into the input of a new task
+func (r *ImportRow) TaskDTO() (CreateTaskDTO, error) {
+	input := CreateTaskDTO{
+		Title:        r.Title,
+		Description:  r.Description,
+		Status:       r.Status,
+		Priority:     r.Priority,
+		Assignee:     r.Assignee,
+		Project:      r.Project,
+		Labels:       r.Labels,
+		CustomFields: r.CustomFields,
+	}
+	if r.ParentID != "" {
+		id, err := primitive.ObjectIDFromHex(r.ParentID)
+		if err != nil {
+			return input, fmt.Errorf("parent_id must be a task ID")
+		}
+		input.ParentID = &id
+	}
+	if r.DueAt != "" {
+		dueAt, err := ParseFieldDate(strings.TrimSpace(r.DueAt))
+		if err != nil {
+			return input, fmt.Errorf("due_at must be a date (YYYY-MM-DD or RFC 3339)")
+		}
+		input.DueAt = &dueAt
+	}
+	return input, nil
+}
+
+// ImportRowResult is the outcome of one row of an import
+type ImportRowResult struct {
+	// Row number in the source: the line for CSV files, counting the header, or the
+	// 1-based index of the item otherwise
+	Row        int      `json:"row" example:"2"`
+	ExternalID string   `json:"external_id,omitempty" example:"PROJ-123"`
+	Status     string   `json:"status" example:"created" enum:"valid,created,duplicate,invalid"`
+	ID         string   `json:"id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Errors     []string `json:"errors,omitempty" example:"title is required"`
+}
+
+// ImportReport reports the outcome of every row of an import
+type ImportReport struct {
+	DryRun     bool              `json:"dry_run" example:"false"`
+	Total      int               `json:"total" example:"3"`
+	Valid      int               `json:"valid" example:"2"`
+	Created    int               `json:"created" example:"1"`
+	Duplicates int               `json:"duplicates" example:"1"`
+	Invalid    int               `json:"invalid" example:"1"`
+	CreatedIDs []string          `json:"created_ids" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Rows       []ImportRowResult `json:"rows"`
+}
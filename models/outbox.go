@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// OutboxMessage is a domain event waiting to be relayed to the event sinks. It is
+// written in the same transaction as the change it describes, so the event is not lost
+// if the process stops before publishing it.
+type OutboxMessage struct {
+	// ID of the event, which sinks use to discard duplicates
+	ID          string   `bson:"_id"`
+	Event       string   `bson:"event"`
+	AggregateID string   `bson:"aggregate_id"`
+	Payload     bson.Raw `bson:"payload"`
+	// Sinks the event was delivered to; it is published once delivered to all of them
+	DeliveredTo []string `bson:"delivered_to"`
+	Attempts    int      `bson:"attempts"`
+	LastError   string   `bson:"last_error,omitempty"`
+	// When the next attempt is due after a failed one
+	NextAttemptAt *time.Time `bson:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at"`
+	PublishedAt   *time.Time `bson:"published_at,omitempty"`
+}
+
+// NewOutboxMessage creates an unpublished message for an encoded event
+func NewOutboxMessage(id, event, aggregateID string, payload bson.Raw) *OutboxMessage {
+	return &OutboxMessage{
+		ID:          id,
+		Event:       event,
+		AggregateID: aggregateID,
+		Payload:     payload,
+		DeliveredTo: []string{},
+		CreatedAt:   time.Now(),
+	}
+}
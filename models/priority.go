@@ -0,0 +1,29 @@
+package models
+
+// Task priorities from lowest to highest
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists the task priorities from lowest to highest
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// PriorityRank returns the position of a priority starting at 1 for low, or 0 if unknown.
+// Ranks are stored alongside the priority so tasks can be filtered and sorted by it.
+func PriorityRank(priority string) int {
+	for i, p := range Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// SetPriority sets the task's priority together with its rank
+func (t *Task) SetPriority(priority string) {
+	t.Priority = priority
+	t.PriorityRank = PriorityRank(priority)
+}
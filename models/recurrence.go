@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecurrenceDTO represents the recurrence rule supplied when creating or updating a task
+type RecurrenceDTO struct {
+	Rule     string `json:"rule" binding:"required" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Timezone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+}
+
+// Recurrence describes how a task repeats. Every occurrence of a series carries a copy
+// of the rule together with the series anchor, so the next due date can be computed
+// from any occurrence.
+type Recurrence struct {
+	Rule       string              `json:"rule" bson:"rule"`                                     // RFC 5545 RRULE value, e.g. FREQ=MONTHLY;BYMONTHDAY=1
+	Timezone   string              `json:"timezone" bson:"timezone"`                             // IANA time zone the rule is evaluated in
+	DTStart    time.Time           `json:"dtstart" bson:"dtstart"`                               // Due date of the first occurrence
+	SeriesID   primitive.ObjectID  `json:"series_id" bson:"series_id"`                           // ID of the first task in the series
+	Sequence   int                 `json:"sequence" bson:"sequence"`                             // Zero-based index of this occurrence
+	NextTaskID *primitive.ObjectID `json:"next_task_id,omitempty" bson:"next_task_id,omitempty"` // Set once the next occurrence has been generated
+	Ended      bool                `json:"ended" bson:"ended"`                                   // No further occurrences will be generated
+}
+
+// NewRecurrence validates the rule and creates a recurrence anchored at the given due date
+func NewRecurrence(input RecurrenceDTO, dueAt time.Time) (*Recurrence, error) {
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	recurrence := &Recurrence{
+		Rule:     strings.TrimPrefix(strings.TrimSpace(input.Rule), "RRULE:"),
+		Timezone: input.Timezone,
+		DTStart:  dueAt,
+	}
+	if _, err := recurrence.rrule(); err != nil {
+		return nil, err
+	}
+	return recurrence, nil
+}
+
+// rrule builds the rule iterator anchored at DTStart in the recurrence's time zone
+func (r *Recurrence) rrule() (*rrule.RRule, error) {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence timezone %q", r.Timezone)
+	}
+	if strings.ContainsAny(r.Rule, "\r\n") || strings.Contains(strings.ToUpper(r.Rule), "DTSTART") {
+		return nil, fmt.Errorf("recurrence rule must be a single RRULE value without DTSTART")
+	}
+
+	option, err := rrule.StrToROptionInLocation(r.Rule, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %v", err)
+	}
+	option.Dtstart = r.DTStart.In(loc)
+	return rrule.NewRRule(*option)
+}
+
+// Occurrences returns up to n due dates of the series strictly after the given time
+func (r *Recurrence) Occurrences(after time.Time, n int) ([]time.Time, error) {
+	rule, err := r.rrule()
+	if err != nil {
+		return nil, err
+	}
+
+	next := rule.Iterator()
+	var occurrences []time.Time
+	for len(occurrences) < n {
+		occurrence, ok := next()
+		if !ok {
+			break
+		}
+		if occurrence.After(after) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences, nil
+}
+
+// Next returns the first due date of the series after the given time, and false if the
+// series has no further occurrences
+func (r *Recurrence) Next(after time.Time) (time.Time, bool, error) {
+	occurrences, err := r.Occurrences(after, 1)
+	if err != nil || len(occurrences) == 0 {
+		return time.Time{}, false, err
+	}
+	return occurrences[0], true, nil
+}
+
+// NextOccurrence creates the task for the occurrence following t, due at dueAt and in the
+// given status. Title, description, labels, assignee and project are copied from t.
+func (t *Task) NextOccurrence(dueAt time.Time, status string) *Task {
+	next := NewTask(t.Title, status)
+	next.Project = t.Project
+	next.ParentID = t.ParentID
+	next.Description = t.Description
+	next.Assignee = t.Assignee
+	if t.Priority != "" {
+		next.SetPriority(t.Priority)
+	}
+	next.Labels = append([]string(nil), t.Labels...)
+	next.DueAt = &dueAt
+	next.Recurrence = &Recurrence{
+		Rule:     t.Recurrence.Rule,
+		Timezone: t.Recurrence.Timezone,
+		DTStart:  t.Recurrence.DTStart,
+		SeriesID: t.Recurrence.SeriesID,
+		Sequence: t.Recurrence.Sequence + 1,
+	}
+	return next
+}
+
+// swagger:model Recurrence
+type RecurrenceResponse struct {
+	Rule       string    `json:"rule" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Timezone   string    `json:"timezone" example:"Europe/Berlin"`
+	DTStart    time.Time `json:"dtstart"`
+	SeriesID   string    `json:"series_id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Sequence   int       `json:"sequence" example:"3"`
+	NextTaskID string    `json:"next_task_id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1e"`
+	Ended      bool      `json:"ended" example:"false"`
+}
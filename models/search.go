@@ -0,0 +1,41 @@
+package models
+
+import (
+	"taskify/utils"
+)
+
+// snippetRadius is the number of characters of context shown around a search match
+const snippetRadius = 60
+
+// TaskSearchResult is a task matched by a full-text search, with its relevance score and
+// highlighted snippets of the fields that matched
+type TaskSearchResult struct {
+	Task       `bson:",inline"`
+	Score      float64           `json:"score" bson:"score"`
+	Highlights map[string]string `json:"highlights,omitempty" bson:"-"`
+}
+
+// Highlight fills Highlights with snippets of the title, description and first matching
+// comment that contain any of the search terms
+func (r *TaskSearchResult) Highlight(terms []string) {
+	r.Highlights = map[string]string{}
+	if snippet, ok := utils.Highlight(r.Title, terms, snippetRadius); ok {
+		r.Highlights["title"] = snippet
+	}
+	if snippet, ok := utils.Highlight(r.Description, terms, snippetRadius); ok {
+		r.Highlights["description"] = snippet
+	}
+	for _, comment := range r.CommentText {
+		if snippet, ok := utils.Highlight(comment, terms, snippetRadius); ok {
+			r.Highlights["comment"] = snippet
+			break
+		}
+	}
+}
+
+// swagger:model TaskSearchResult
+type TaskSearchResultResponse struct {
+	TaskResponse
+	Score      float64           `json:"score" example:"1.75"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
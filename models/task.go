@@ -1,61 +1,196 @@
 package models
 
 import (
+	"errors"
 	"time"
-	"taskify/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"taskify/utils"
 )
 
 // CreateTaskDTO represents the data needed to create a new task
 type CreateTaskDTO struct {
-	Title       string `json:"title" binding:"required,min=3,max=100" example:"Complete project documentation"`
-	Description string `json:"description,omitempty" binding:"omitempty,max=500" example:"Write comprehensive documentation for the project"`
-	Status      string `json:"status,omitempty" binding:"omitempty,oneof=pending in_progress completed" example:"pending"`
+	Title       string         `json:"title" binding:"required,min=3,max=100" example:"Complete project documentation"`
+	Description string         `json:"description,omitempty" binding:"omitempty,max=500" example:"Write comprehensive documentation for the project"`
+	Status      string         `json:"status,omitempty" binding:"omitempty,max=50" example:"pending"`
+	Priority    string         `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent" example:"medium"`
+	Assignee    string         `json:"assignee,omitempty" example:"johndoe"`
+	DueAt       *time.Time     `json:"due_at,omitempty" example:"2024-01-15T09:00:00Z"`
+	Labels      []string       `json:"labels,omitempty" binding:"omitempty,max=20,dive,min=1,max=50" example:"finance"`
+	Recurrence  *RecurrenceDTO `json:"recurrence,omitempty"`
+	Project     string         `json:"project,omitempty" binding:"omitempty,max=100" example:"website"`
+	// ID of the task this task is a subtask of
+	ParentID     *primitive.ObjectID    `json:"parent_id,omitempty" swaggertype:"string" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// UpdateTaskDTO is the full writable representation of a task. PUT replaces a task with
+// it: omitted fields are cleared, and priority falls back to medium. PATCH applies the
+// patch to the task's current UpdateTaskDTO.
+type UpdateTaskDTO struct {
+	Title       string         `json:"title" binding:"required,min=3,max=100" example:"Complete project documentation"`
+	Description string         `json:"description,omitempty" binding:"omitempty,max=500" example:"Write comprehensive documentation for the project"`
+	Status      string         `json:"status" binding:"required,max=50" example:"in_progress"`
+	Priority    string         `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent" example:"high"`
+	Assignee    string         `json:"assignee,omitempty" example:"johndoe"`
+	DueAt       *time.Time     `json:"due_at,omitempty" example:"2024-01-15T09:00:00Z"`
+	Labels      []string       `json:"labels,omitempty" binding:"omitempty,max=20,dive,min=1,max=50" example:"finance"`
+	Recurrence  *RecurrenceDTO `json:"recurrence,omitempty"`
+	// Values of custom fields; values of archived fields cannot be changed and are kept
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // Task represents a task in the system
 type Task struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Title       string            `json:"title" bson:"title" binding:"required,min=3,max=100"`
-	Description string            `json:"description,omitempty" bson:"description" binding:"omitempty,max=500"`
-	Status      string            `json:"status,omitempty" bson:"status" binding:"omitempty,oneof=pending in_progress completed"`
-	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+	Title       string             `json:"title" bson:"title" binding:"required,min=3,max=100"`
+	Description string             `json:"description,omitempty" bson:"description" binding:"omitempty,max=500"`
+	Status      string             `json:"status,omitempty" bson:"status" binding:"omitempty,max=50"`
+	Priority    string             `json:"priority,omitempty" bson:"priority,omitempty"`
+	// Position of the priority in Priorities, for range filters and sorting
+	PriorityRank int                 `json:"-" bson:"priority_rank,omitempty"`
+	Project      string              `json:"project,omitempty" bson:"project,omitempty"`
+	Assignee     string              `json:"assignee,omitempty" bson:"assignee"`
+	ParentID     *primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	ExternalID   string              `json:"external_id,omitempty" bson:"external_id,omitempty"`
+	DueAt        *time.Time          `json:"due_at,omitempty" bson:"due_at,omitempty"`
+	Labels       []string            `json:"labels,omitempty" bson:"labels,omitempty"`
+	Recurrence   *Recurrence         `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// Values of admin-defined custom fields keyed by field key
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+	// Bodies of the task's comments, denormalized for the full-text search index
+	CommentText []string `json:"-" bson:"comment_text,omitempty"`
+	// Address of the task in the CalDAV client that created it
+	CalendarObject *CalendarObject `json:"-" bson:"calendar_object,omitempty"`
+	// Incremented on every change; used as the task's ETag
+	Version   int64      `json:"version" bson:"version"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
 }
 
-// NewTask creates a new task with default values
-func NewTask(title string) *Task {
+// NewTask creates a new task in the given status with default values
+func NewTask(title, status string) *Task {
 	now := time.Now()
-	return &Task{
+	task := &Task{
 		Title:     title,
-		Status:    "pending",
+		Status:    status,
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
+	task.SetPriority(PriorityMedium)
+	return task
 }
 
-// Update updates task fields with non-empty values
-func (t *Task) Update(title, description, status string) error {
-	if title != "" {
-		t.Title = title
+// Replace replaces the writable fields of the task with the given values. Custom fields
+// are replaced separately as they need their definitions.
+func (t *Task) Replace(input UpdateTaskDTO) error {
+	t.Title = input.Title
+	t.Description = input.Description
+	t.Status = input.Status
+	if input.Priority == "" {
+		input.Priority = PriorityMedium
 	}
-	if description != "" {
-		t.Description = description
+	t.SetPriority(input.Priority)
+	t.Assignee = input.Assignee
+	t.DueAt = input.DueAt
+	t.Labels = nil
+	if len(input.Labels) > 0 {
+		t.Labels = input.Labels
 	}
-	if status != "" {
-		t.Status = status
+
+	// Keep the series of a recurring task unless its rule changes
+	switch {
+	case input.Recurrence == nil:
+		t.Recurrence = nil
+	case t.Recurrence != nil && t.Recurrence.Rule == input.Recurrence.Rule && t.Recurrence.Timezone == input.Recurrence.Timezone:
+	default:
+		if err := t.SetRecurrence(*input.Recurrence); err != nil {
+			return err
+		}
 	}
+
 	t.UpdatedAt = time.Now()
 	return utils.ValidateStruct(t)
 }
 
+// Document returns the current writable representation of the task
+func (t *Task) Document() UpdateTaskDTO {
+	doc := UpdateTaskDTO{
+		Title:        t.Title,
+		Description:  t.Description,
+		Status:       t.Status,
+		Priority:     t.Priority,
+		Assignee:     t.Assignee,
+		DueAt:        t.DueAt,
+		Labels:       t.Labels,
+		CustomFields: t.CustomFields,
+	}
+	if t.Recurrence != nil {
+		doc.Recurrence = &RecurrenceDTO{Rule: t.Recurrence.Rule, Timezone: t.Recurrence.Timezone}
+	}
+	return doc
+}
+
+// SetRecurrence makes the task repeat according to the given rule, starting a new series
+// anchored at the task's due date
+func (t *Task) SetRecurrence(input RecurrenceDTO) error {
+	if t.DueAt == nil {
+		return errors.New("due_at is required for recurring tasks")
+	}
+	recurrence, err := NewRecurrence(input, *t.DueAt)
+	if err != nil {
+		return err
+	}
+	recurrence.SeriesID = t.ID
+	t.Recurrence = recurrence
+	return nil
+}
+
 // swagger:model Task
 type TaskResponse struct {
-	ID          string    `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
-	Title       string    `json:"title" example:"Complete project documentation" minLength:"3" maxLength:"100"`
-	Description string    `json:"description" example:"Write comprehensive documentation for the Taskify project" maxLength:"500"`
-	Status      string    `json:"status" example:"pending" enum:"pending,in_progress,completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           string                 `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	Title        string                 `json:"title" example:"Complete project documentation" minLength:"3" maxLength:"100"`
+	Description  string                 `json:"description" example:"Write comprehensive documentation for the Taskify project" maxLength:"500"`
+	Status       string                 `json:"status" example:"pending"`
+	Priority     string                 `json:"priority,omitempty" example:"medium" enum:"low,medium,high,urgent"`
+	Project      string                 `json:"project,omitempty" example:"website"`
+	Assignee     string                 `json:"assignee,omitempty" example:"johndoe"`
+	ParentID     string                 `json:"parent_id,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1a"`
+	ExternalID   string                 `json:"external_id,omitempty" example:"PROJ-123"`
+	DueAt        *time.Time             `json:"due_at,omitempty"`
+	Labels       []string               `json:"labels,omitempty" example:"finance"`
+	Recurrence   *RecurrenceResponse    `json:"recurrence,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+	Version      int64                  `json:"version" example:"3"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	DeletedAt    *time.Time             `json:"deleted_at,omitempty"`
+	DeletedBy    string                 `json:"deleted_by,omitempty" example:"johndoe"`
+	// Related data embedded with include=
+	AssigneeUser *UserResponse  `json:"assignee_user,omitempty"`
+	CommentCount *int           `json:"comment_count,omitempty" example:"4"`
+	Subtasks     []TaskResponse `json:"subtasks,omitempty"`
+}
+
+// swagger:model TaskList
+type TaskListResponse struct {
+	Data []TaskResponse `json:"data"`
+	// Cursor of the next page; null on the last page
+	NextCursor *string `json:"next_cursor" example:"JAAAAAJzAAEAAAAABGEAFAAAAAcwAGrVZtFZdHkRKncYMwAA"`
+	Total      int64   `json:"total" example:"42"`
+	// Page and limit are only returned in the deprecated page mode
+	Page  int `json:"page,omitempty" example:"1"`
+	Limit int `json:"limit,omitempty" example:"10"`
+}
+
+// swagger:model TrashList
+type TrashListResponse struct {
+	Data  []TaskResponse `json:"data"`
+	Page  int            `json:"page" example:"1"`
+	Limit int            `json:"limit" example:"10"`
+	Total int64          `json:"total" example:"42"`
 }
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// CreateWebhookDTO represents the data needed to subscribe to webhook events
+type CreateWebhookDTO struct {
+	URL string `json:"url" binding:"required,url,max=2048" example:"https://example.com/hooks/taskify"`
+	// Shared secret the payloads are signed with
+	Secret string   `json:"secret" binding:"required,min=16,max=256" example:"7f3c9a1e5b2d4c6e8f0a"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=task.created task.updated task.deleted task.restored task.purged task.commented" example:"task.created"`
+}
+
+// UpdateWebhookDTO represents the changes allowed to a webhook. The secret is kept when
+// omitted; activating a webhook that was disabled after failing resets its failure count.
+type UpdateWebhookDTO struct {
+	URL    string   `json:"url" binding:"required,url,max=2048" example:"https://example.com/hooks/taskify"`
+	Secret string   `json:"secret,omitempty" binding:"omitempty,min=16,max=256" example:"7f3c9a1e5b2d4c6e8f0a"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=task.created task.updated task.deleted task.restored task.purged task.commented" example:"task.created"`
+	Active bool     `json:"active" example:"true"`
+}
+
+// Webhook subscribes a URL to task events. Deliveries are signed with the secret, which
+// is never returned by the API.
+type Webhook struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL       string             `json:"url" bson:"url"`
+	Secret    string             `json:"-" bson:"secret"`
+	Events    []string           `json:"events" bson:"events"`
+	Active    bool               `json:"active" bson:"active"`
+	CreatedBy string             `json:"created_by" bson:"created_by"`
+	// Deliveries that failed in a row; the webhook is disabled when it reaches the limit
+	ConsecutiveFailures int        `json:"consecutive_failures" bson:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" bson:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// NewWebhook creates an active webhook
+func NewWebhook(input CreateWebhookDTO, createdBy string) (*Webhook, error) {
+	if err := checkWebhookURL(input.URL); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Webhook{
+		URL:       input.URL,
+		Secret:    input.Secret,
+		Events:    input.Events,
+		Active:    true,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Update applies the changes to the webhook
+func (w *Webhook) Update(input UpdateWebhookDTO) error {
+	if err := checkWebhookURL(input.URL); err != nil {
+		return err
+	}
+	w.URL = input.URL
+	if input.Secret != "" {
+		w.Secret = input.Secret
+	}
+	w.Events = input.Events
+	if input.Active && !w.Active {
+		w.ConsecutiveFailures = 0
+		w.DisabledAt = nil
+	}
+	w.Active = input.Active
+	w.UpdatedAt = time.Now()
+	return nil
+}
+
+// checkWebhookURL returns an error unless the URL is an absolute HTTP(S) URL
+func checkWebhookURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// WebhookAttempt records one attempt to deliver an event
+type WebhookAttempt struct {
+	At time.Time `json:"at" bson:"at"`
+	// HTTP status of the response; absent if no response was received
+	StatusCode int    `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	// Start of the response body
+	Response string `json:"response,omitempty" bson:"response,omitempty"`
+	Duration int64  `json:"duration_ms" bson:"duration_ms"`
+}
+
+// WebhookDelivery is the delivery of one event to one webhook, retried with exponential
+// backoff until it succeeds or runs out of attempts
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookID primitive.ObjectID `json:"webhook_id" bson:"webhook_id"`
+	EventID   string             `json:"event_id" bson:"event_id"`
+	Event     string             `json:"event" bson:"event"`
+	Payload   json.RawMessage    `json:"payload" bson:"payload"`
+	Status    string             `json:"status" bson:"status"`
+	Attempts  []WebhookAttempt   `json:"attempts" bson:"attempts"`
+	// When the next attempt is due while the delivery is pending
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	// Delivery this one was created to redeliver
+	RedeliveryOf *primitive.ObjectID `json:"redelivery_of,omitempty" bson:"redelivery_of,omitempty"`
+	CreatedAt    time.Time           `json:"created_at" bson:"created_at"`
+	CompletedAt  *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// NewWebhookDelivery creates a pending delivery of an event, due immediately
+func NewWebhookDelivery(webhookID primitive.ObjectID, eventID, event string, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		Event:         event,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		Attempts:      []WebhookAttempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+}
+
+// swagger:model Webhook
+type WebhookResponse struct {
+	ID                  string     `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1e"`
+	URL                 string     `json:"url" example:"https://example.com/hooks/taskify"`
+	Events              []string   `json:"events" example:"task.created,task.updated"`
+	Active              bool       `json:"active" example:"true"`
+	CreatedBy           string     `json:"created_by" example:"admin"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// swagger:model WebhookAttempt
+type WebhookAttemptResponse struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty" example:"502"`
+	Error      string    `json:"error,omitempty" example:"unexpected status 502 Bad Gateway"`
+	Response   string    `json:"response,omitempty" example:"upstream unavailable"`
+	Duration   int64     `json:"duration_ms" example:"184"`
+}
+
+// swagger:model WebhookDelivery
+type WebhookDeliveryResponse struct {
+	ID            string                   `json:"id" example:"5f7b5e1b9b0b3a1b3c9b4b1f"`
+	WebhookID     string                   `json:"webhook_id" example:"5f7b5e1b9b0b3a1b3c9b4b1e"`
+	EventID       string                   `json:"event_id" example:"5f7b5e1b9b0b3a1b3c9b4b1c"`
+	Event         string                   `json:"event" example:"task.updated"`
+	Payload       map[string]interface{}   `json:"payload"`
+	Status        string                   `json:"status" example:"pending" enum:"pending,succeeded,failed"`
+	Attempts      []WebhookAttemptResponse `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty"`
+	RedeliveryOf  string                   `json:"redelivery_of,omitempty" example:"5f7b5e1b9b0b3a1b3c9b4b1d"`
+	CreatedAt     time.Time                `json:"created_at"`
+	CompletedAt   *time.Time               `json:"completed_at,omitempty"`
+}
+
+// swagger:model WebhookDeliveryList
+type WebhookDeliveryListResponse struct {
+	Data  []WebhookDeliveryResponse `json:"data"`
+	Page  int                       `json:"page" example:"1"`
+	Limit int                       `json:"limit" example:"10"`
+	Total int64                     `json:"total" example:"42"`
+}
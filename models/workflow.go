@@ -0,0 +1,191 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status categories group workflow statuses by how far along a task is
+const (
+	CategoryTodo  = "todo"
+	CategoryDoing = "doing"
+	CategoryDone  = "done"
+)
+
+// AnyStatus matches every status in the From field of a transition
+const AnyStatus = "*"
+
+// WorkflowStatus is a named status a task can be in
+type WorkflowStatus struct {
+	Name     string `json:"name" bson:"name" binding:"required,min=1,max=50" example:"in_review"`
+	Category string `json:"category" bson:"category" binding:"required,oneof=todo doing done" example:"doing"`
+}
+
+// Transition allows tasks to move from one status to another, optionally only for some roles
+type Transition struct {
+	From  string   `json:"from" bson:"from" binding:"required" example:"in_progress"`
+	To    string   `json:"to" bson:"to" binding:"required" example:"in_review"`
+	Roles []string `json:"roles,omitempty" bson:"roles,omitempty" binding:"omitempty,dive,oneof=admin editor viewer" example:"editor"`
+}
+
+// Workflow defines the statuses of a task and the allowed transitions between them
+type Workflow struct {
+	Name          string           `json:"name" bson:"name" binding:"required,min=1,max=100" example:"Software delivery"`
+	InitialStatus string           `json:"initial_status" bson:"initial_status" binding:"required" example:"pending"`
+	Statuses      []WorkflowStatus `json:"statuses" bson:"statuses" binding:"required,min=1,dive"`
+	Transitions   []Transition     `json:"transitions" bson:"transitions" binding:"dive"`
+}
+
+// TransitionError explains why a status change was rejected
+type TransitionError struct {
+	From      string
+	To        string
+	Forbidden bool // The transition exists but the role is not allowed to perform it
+	Allowed   []string
+}
+
+func (e *TransitionError) Error() string {
+	if e.Forbidden {
+		return fmt.Sprintf("your role is not allowed to move a task from %q to %q", e.From, e.To)
+	}
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("status %q cannot be changed to %q: no transitions are allowed from %q", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("status %q cannot be changed to %q; allowed: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// Validate checks that the workflow is internally consistent
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("workflow %q must define at least one status", w.Name)
+	}
+
+	seen := map[string]bool{}
+	for _, status := range w.Statuses {
+		if status.Name == "" || status.Name == AnyStatus {
+			return fmt.Errorf("workflow %q has an invalid status name %q", w.Name, status.Name)
+		}
+		if seen[status.Name] {
+			return fmt.Errorf("workflow %q defines status %q more than once", w.Name, status.Name)
+		}
+		switch status.Category {
+		case CategoryTodo, CategoryDoing, CategoryDone:
+		default:
+			return fmt.Errorf("status %q has invalid category %q (must be todo, doing or done)", status.Name, status.Category)
+		}
+		seen[status.Name] = true
+	}
+
+	if !seen[w.InitialStatus] {
+		return fmt.Errorf("initial status %q is not defined in workflow %q", w.InitialStatus, w.Name)
+	}
+	for _, transition := range w.Transitions {
+		if transition.From != AnyStatus && !seen[transition.From] {
+			return fmt.Errorf("transition from unknown status %q", transition.From)
+		}
+		if !seen[transition.To] {
+			return fmt.Errorf("transition to unknown status %q", transition.To)
+		}
+	}
+	return nil
+}
+
+// StatusNames returns the names of all statuses in the order they are defined
+func (w *Workflow) StatusNames() []string {
+	names := make([]string, 0, len(w.Statuses))
+	for _, status := range w.Statuses {
+		names = append(names, status.Name)
+	}
+	return names
+}
+
+// HasStatus reports whether the workflow defines the named status
+func (w *Workflow) HasStatus(name string) bool {
+	return w.Category(name) != ""
+}
+
+// Category returns the category of the named status, or an empty string if it is not defined
+func (w *Workflow) Category(name string) string {
+	for _, status := range w.Statuses {
+		if status.Name == name {
+			return status.Category
+		}
+	}
+	return ""
+}
+
+// StatusIn returns the first status of the category, preferring the initial status, or an
+// empty string if the workflow has no status in it
+func (w *Workflow) StatusIn(category string) string {
+	if w.Category(w.InitialStatus) == category {
+		return w.InitialStatus
+	}
+	for _, status := range w.Statuses {
+		if status.Category == category {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// IsDone reports whether the named status is in the done category
+func (w *Workflow) IsDone(name string) bool {
+	return w.Category(name) == CategoryDone
+}
+
+// CheckStatus returns an error if the workflow does not define the named status
+func (w *Workflow) CheckStatus(name string) error {
+	if !w.HasStatus(name) {
+		return fmt.Errorf("status must be one of: %s", strings.Join(w.StatusNames(), ", "))
+	}
+	return nil
+}
+
+// CheckTransition returns a *TransitionError if a user with the given role may not move a
+// task from one status to another. Keeping the same status is always allowed.
+func (w *Workflow) CheckTransition(from, to, role string) error {
+	if err := w.CheckStatus(to); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+
+	transitionErr := &TransitionError{From: from, To: to}
+	for _, transition := range w.Transitions {
+		if transition.From != from && transition.From != AnyStatus {
+			continue
+		}
+		if transition.To != to {
+			transitionErr.Allowed = appendUnique(transitionErr.Allowed, transition.To)
+			continue
+		}
+		if len(transition.Roles) == 0 || containsString(transition.Roles, role) {
+			return nil
+		}
+		transitionErr.Forbidden = true
+	}
+	return transitionErr
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectWorkflow stores the workflow used by the tasks of a project
+type ProjectWorkflow struct {
+	Project  string   `json:"project" bson:"_id"`
+	Workflow Workflow `json:"workflow" bson:"workflow"`
+}
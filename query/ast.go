@@ -0,0 +1,64 @@
+package query
+
+// Node is a node of a parsed filter expression
+type Node interface {
+	node()
+}
+
+// And matches when both sides match
+type And struct {
+	Left, Right Node
+}
+
+// Or matches when either side matches
+type Or struct {
+	Left, Right Node
+}
+
+// Not matches when its expression does not match
+type Not struct {
+	Expr Node
+}
+
+// Comparison compares a field with a single value using one of = != > >= < <=
+type Comparison struct {
+	Field Ref
+	Op    string
+	OpPos int
+	Value Value
+}
+
+// In matches when a field equals any of the values, or none of them when Negated
+type In struct {
+	Field   Ref
+	Values  []Value
+	Negated bool
+}
+
+// Null matches when a field has no value, or has one when Negated
+type Null struct {
+	Field   Ref
+	Negated bool
+}
+
+// Ref is a reference to a field in a filter expression
+type Ref struct {
+	Name string
+	Pos  int
+}
+
+// Value is a literal in a filter expression. Typed holds the value converted to the
+// type of the compared field once the expression has been validated.
+type Value struct {
+	Raw    string
+	Quoted bool
+	Pos    int
+	Typed  interface{}
+}
+
+func (And) node()         {}
+func (Or) node()          {}
+func (Not) node()         {}
+func (*Comparison) node() {}
+func (*In) node()         {}
+func (*Null) node()       {}
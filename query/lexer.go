@@ -0,0 +1,130 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexical token together with its byte offset in the input
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	quote bool
+}
+
+// keyword reports whether the token is the given keyword, ignoring case
+func (t token) keyword(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// SyntaxError reports a problem with a filter expression at a position in the input
+type SyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Message)
+}
+
+// isWordBreak reports whether r ends a bare word
+func isWordBreak(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`(),=!<>"'`, r)
+}
+
+// lex splits a filter expression into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	// i is the byte offset of the next rune, so that positions are found in constant time
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(input) && input[i] == '=' && r != '=' {
+				i++
+			}
+			op := input[start:i]
+			if op == "!" {
+				return nil, &SyntaxError{Pos: start, Message: "unexpected '!', did you mean '!='?"}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var text strings.Builder
+			for i++; ; {
+				if i >= len(input) {
+					return nil, &SyntaxError{Pos: start, Message: "unterminated string"}
+				}
+				c, size := utf8.DecodeRuneInString(input[i:])
+				if c == '\\' && i+size < len(input) {
+					i += size
+					c, size = utf8.DecodeRuneInString(input[i:])
+					text.WriteRune(c)
+					i += size
+					continue
+				}
+				i += size
+				if c == r {
+					break
+				}
+				text.WriteRune(c)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text.String(), pos: start, quote: true})
+
+		default:
+			start := i
+			for i < len(input) {
+				c, size := utf8.DecodeRuneInString(input[i:])
+				if isWordBreak(c) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[start:i], pos: start})
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(input)})
+	return tokens, nil
+}
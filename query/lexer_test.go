@@ -0,0 +1,71 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexPositions(t *testing.T) {
+	tokens, err := lex(`é = 'a' or b>=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []token{
+		{kind: tokenWord, text: "é", pos: 0},
+		{kind: tokenOperator, text: "=", pos: 3},
+		{kind: tokenString, text: "a", pos: 5, quote: true},
+		{kind: tokenWord, text: "or", pos: 9},
+		{kind: tokenWord, text: "b", pos: 12},
+		{kind: tokenOperator, text: ">=", pos: 13},
+		{kind: tokenWord, text: "1", pos: 15},
+		{kind: tokenEOF, pos: 16},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("got %d tokens, want %d: %v", len(tokens), len(expected), tokens)
+	}
+	for i, want := range expected {
+		if tokens[i] != want {
+			t.Errorf("token %d = %+v, want %+v", i, tokens[i], want)
+		}
+	}
+}
+
+func TestLexStrings(t *testing.T) {
+	tests := []struct {
+		input string
+		text  string
+	}{
+		{`"open"`, "open"},
+		{`'in progress'`, "in progress"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`'it\'s'`, "it's"},
+		{`"ünïcode"`, "ünïcode"},
+		{`""`, ""},
+	}
+	for _, tt := range tests {
+		tokens, err := lex(tt.input)
+		if err != nil {
+			t.Errorf("lex(%s) failed: %v", tt.input, err)
+			continue
+		}
+		if tokens[0].kind != tokenString || tokens[0].text != tt.text {
+			t.Errorf("lex(%s) = %+v, want string %q", tt.input, tokens[0], tt.text)
+		}
+	}
+}
+
+func TestLexLongInput(t *testing.T) {
+	// Lexing is linear in the length of the input
+	input := strings.Repeat("título = 'ä' and ", 50000)
+	tokens, err := lex(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 4*50000+1 {
+		t.Fatalf("got %d tokens", len(tokens))
+	}
+	if last := tokens[len(tokens)-2]; last.pos != len(input)-4 {
+		t.Errorf("last token at %d, want %d", last.pos, len(input)-4)
+	}
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var mongoOperators = map[string]string{
+	"=":  "$eq",
+	"!=": "$ne",
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+}
+
+// ToBSON translates a validated expression into a MongoDB filter
+func (s Schema) ToBSON(node Node) bson.M {
+	switch n := node.(type) {
+	case *And:
+		return bson.M{"$and": bson.A{s.ToBSON(n.Left), s.ToBSON(n.Right)}}
+	case *Or:
+		return bson.M{"$or": bson.A{s.ToBSON(n.Left), s.ToBSON(n.Right)}}
+	case *Not:
+		return bson.M{"$nor": bson.A{s.ToBSON(n.Expr)}}
+
+	case *Comparison:
+		return bson.M{s[n.Field.Name].Path: bson.M{mongoOperators[n.Op]: n.Value.Typed}}
+
+	case *In:
+		values := make(bson.A, len(n.Values))
+		for i, v := range n.Values {
+			values[i] = v.Typed
+		}
+		op := "$in"
+		if n.Negated {
+			op = "$nin"
+		}
+		return bson.M{s[n.Field.Name].Path: bson.M{op: values}}
+
+	case *Null:
+		if n.Negated {
+			return bson.M{s[n.Field.Name].Path: bson.M{"$ne": nil}}
+		}
+		return bson.M{s[n.Field.Name].Path: nil}
+	}
+	return bson.M{}
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestToBSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bson.M
+	}{
+		{"status = open", bson.M{"status": bson.M{"$eq": "open"}}},
+		{"status != 'in progress'", bson.M{"status": bson.M{"$ne": "in progress"}}},
+		{"priority >= medium", bson.M{"priority_rank": bson.M{"$gte": 2}}},
+		{"points < 3.5", bson.M{"custom_fields.points": bson.M{"$lt": 3.5}}},
+		{"due_at <= -7d", bson.M{"due_at": bson.M{"$lte": testNow.AddDate(0, 0, -7)}}},
+		{"status in (open, done)", bson.M{"status": bson.M{"$in": bson.A{"open", "done"}}}},
+		{"labels not in (bug)", bson.M{"labels": bson.M{"$nin": bson.A{"bug"}}}},
+		{"due_at is null", bson.M{"due_at": nil}},
+		{"due_at is not null", bson.M{"due_at": bson.M{"$ne": nil}}},
+		{
+			"status = a and priority = high",
+			bson.M{"$and": bson.A{
+				bson.M{"status": bson.M{"$eq": "a"}},
+				bson.M{"priority_rank": bson.M{"$eq": 3}},
+			}},
+		},
+		{
+			"not (status = a or points > 3)",
+			bson.M{"$nor": bson.A{
+				bson.M{"$or": bson.A{
+					bson.M{"status": bson.M{"$eq": "a"}},
+					bson.M{"custom_fields.points": bson.M{"$gt": 3.0}},
+				}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		got := testSchema.ToBSON(parseValid(t, tt.input))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToBSON(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDepth bounds the nesting of parentheses and not operators
+const maxDepth = 32
+
+// Parse parses a filter expression such as
+//
+//	status in (pending, in_progress) and priority >= high and updated_at > -7d
+//
+// The grammar, from lowest to highest precedence, is:
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | predicate
+//	predicate  = field op value
+//	           | field ["not"] "in" "(" value { "," value } ")"
+//	           | field "is" ["not"] "null"
+//
+// Keywords are case-insensitive. Values are bare words or quoted strings.
+func Parse(input string) (Node, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, &SyntaxError{Pos: 0, Message: "filter is empty"}
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.unexpected(next, "expected 'and', 'or' or end of filter")
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	return &SyntaxError{Pos: t.pos, Message: fmt.Sprintf("unexpected %s, %s", t, expected)}
+}
+
+func (p *parser) enter(t token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &SyntaxError{Pos: t.pos, Message: "filter is nested too deeply"}
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (Node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or") {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and") {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseFactor() (Node, error) {
+	t := p.peek()
+	switch {
+	case t.keyword("not"):
+		p.next()
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		expr, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+
+	case t.kind == tokenLParen:
+		p.next()
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.unexpected(closing, "expected ')'")
+		}
+		return expr, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (Node, error) {
+	t := p.next()
+	if t.kind != tokenWord || isKeyword(t.text) {
+		return nil, p.unexpected(t, "expected a field name")
+	}
+	field := Ref{Name: t.text, Pos: t.pos}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenOperator:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &Comparison{Field: field, Op: op.text, OpPos: op.pos, Value: value}, nil
+
+	case op.keyword("in"):
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &In{Field: field, Values: values}, nil
+
+	case op.keyword("not"):
+		if in := p.next(); !in.keyword("in") {
+			return nil, p.unexpected(in, "expected 'in' after 'not'")
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &In{Field: field, Values: values, Negated: true}, nil
+
+	case op.keyword("is"):
+		negated := false
+		if p.peek().keyword("not") {
+			p.next()
+			negated = true
+		}
+		if null := p.next(); !null.keyword("null") {
+			return nil, p.unexpected(null, "expected 'null'")
+		}
+		return &Null{Field: field, Negated: negated}, nil
+	}
+	return nil, p.unexpected(op, "expected an operator (=, !=, >, >=, <, <=, in, not in, is null)")
+}
+
+func (p *parser) parseList() ([]Value, error) {
+	if open := p.next(); open.kind != tokenLParen {
+		return nil, p.unexpected(open, "expected '('")
+	}
+
+	var values []Value
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch t := p.next(); t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, p.unexpected(t, "expected ',' or ')'")
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.next()
+	if t.kind == tokenString || (t.kind == tokenWord && !isKeyword(t.text)) {
+		return Value{Raw: t.text, Quoted: t.quote, Pos: t.pos}, nil
+	}
+	return Value{}, p.unexpected(t, "expected a value")
+}
+
+// isKeyword reports whether a bare word is reserved by the grammar
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in", "is", "null":
+		return true
+	}
+	return false
+}
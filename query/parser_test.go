@@ -0,0 +1,101 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{"", 0, "filter is empty"},
+		{"   ", 0, "filter is empty"},
+		{`status = "open`, 9, "unterminated string"},
+		{"status ! open", 7, "did you mean '!='?"},
+		{"status = open and", 17, "unexpected end of input, expected a field name"},
+		{"status open", 7, "expected an operator"},
+		{"ü = 1 )", 7, "unexpected ')', expected 'and', 'or' or end of filter"},
+		{"status in (a b)", 13, "expected ',' or ')'"},
+		{"status in a", 10, "expected '('"},
+		{"status not like (a)", 11, "expected 'in' after 'not'"},
+		{"status is nil", 10, "expected 'null'"},
+		{"(status = a", 11, "expected ')'"},
+		{"and = 1", 0, "expected a field name"},
+		{"status = or", 9, "expected a value"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Parse(%q) error = %v, want a syntax error", tt.input, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Message, tt.message) {
+			t.Errorf("Parse(%q) error = %v, want %q at position %d", tt.input, err, tt.message, tt.pos)
+		}
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	node, err := Parse("a = 1 or not b = 2 and (c is not null)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	or, ok := node.(*Or)
+	if !ok {
+		t.Fatalf("root is %T, want *Or", node)
+	}
+	if _, ok := or.Left.(*Comparison); !ok {
+		t.Errorf("left of or is %T, want *Comparison", or.Left)
+	}
+	and, ok := or.Right.(*And)
+	if !ok {
+		t.Fatalf("right of or is %T, want *And", or.Right)
+	}
+	if _, ok := and.Left.(*Not); !ok {
+		t.Errorf("left of and is %T, want *Not", and.Left)
+	}
+	if null, ok := and.Right.(*Null); !ok || !null.Negated {
+		t.Errorf("right of and is %#v, want a negated *Null", and.Right)
+	}
+}
+
+func TestParseKeywordsIgnoreCase(t *testing.T) {
+	node, err := Parse("status NOT IN (a) AND due_at IS NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	and := node.(*And)
+	if in, ok := and.Left.(*In); !ok || !in.Negated || len(in.Values) != 1 {
+		t.Errorf("left of and is %#v, want a negated *In", and.Left)
+	}
+}
+
+func TestParseDepthLimit(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat("(", n) + "a = 1" + strings.Repeat(")", n)
+	}
+	if _, err := Parse(nested(maxDepth)); err != nil {
+		t.Errorf("Parse of %d parentheses failed: %v", maxDepth, err)
+	}
+
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{nested(maxDepth + 1), maxDepth},
+		{strings.Repeat("not ", maxDepth+1) + "a = 1", 4 * maxDepth},
+		{nested(100000), maxDepth},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok || syntaxErr.Pos != tt.pos || syntaxErr.Message != "filter is nested too deeply" {
+			t.Errorf("Parse of %d bytes error = %v, want too deep at position %d", len(tt.input), err, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType determines which operators a field supports and how its values are parsed
+type FieldType int
+
+const (
+	// TypeString fields support equality, in and null checks
+	TypeString FieldType = iota
+	// TypeNumber fields are compared numerically
+	TypeNumber
+	// TypeDate fields take absolute dates (YYYY-MM-DD or RFC 3339) or relative ones
+	// such as -7d, +2w, -3h, now and today
+	TypeDate
+	// TypeOrdered fields take one of a fixed list of names and are compared by their
+	// position in the list, stored as a rank starting at 1
+	TypeOrdered
+)
+
+// Field describes a field that may be used in a filter
+type Field struct {
+	// Path is the document path of the field in MongoDB
+	Path string
+	// Column is the SQL column of the field; empty if the field is not available in SQL
+	Column string
+	// ExistsFrom makes the field multi-valued in SQL: predicates become EXISTS subqueries
+	// over this FROM clause, e.g. "task_labels WHERE task_labels.task_id = tasks.id"
+	ExistsFrom string
+	Type       FieldType
+	// Values lists the names of a TypeOrdered field from lowest to highest
+	Values []string
+}
+
+// Schema is the whitelist of fields a filter may reference, keyed by name
+type Schema map[string]Field
+
+var relativeDate = regexp.MustCompile(`^([+-])(\d+)([mhdw])$`)
+
+// Validate checks every field and value of the expression against the schema and converts
+// values to the type of their field. Relative dates are resolved against now.
+func (s Schema) Validate(node Node, now time.Time) error {
+	switch n := node.(type) {
+	case *And:
+		if err := s.Validate(n.Left, now); err != nil {
+			return err
+		}
+		return s.Validate(n.Right, now)
+	case *Or:
+		if err := s.Validate(n.Left, now); err != nil {
+			return err
+		}
+		return s.Validate(n.Right, now)
+	case *Not:
+		return s.Validate(n.Expr, now)
+
+	case *Comparison:
+		field, err := s.lookup(n.Field)
+		if err != nil {
+			return err
+		}
+		if n.Op != "=" && n.Op != "!=" && field.Type == TypeString {
+			return &SyntaxError{Pos: n.OpPos, Message: fmt.Sprintf("field '%s' does not support %s", n.Field.Name, n.Op)}
+		}
+		return field.convert(&n.Value, now)
+
+	case *In:
+		field, err := s.lookup(n.Field)
+		if err != nil {
+			return err
+		}
+		for i := range n.Values {
+			if err := field.convert(&n.Values[i], now); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *Null:
+		_, err := s.lookup(n.Field)
+		return err
+	}
+	return fmt.Errorf("unsupported filter node %T", node)
+}
+
+func (s Schema) lookup(ref Ref) (Field, error) {
+	field, ok := s[ref.Name]
+	if !ok {
+		return Field{}, &SyntaxError{Pos: ref.Pos, Message: fmt.Sprintf("unknown field '%s'", ref.Name)}
+	}
+	return field, nil
+}
+
+// convert parses the raw value into the type of the field
+func (f Field) convert(v *Value, now time.Time) error {
+	invalid := func(expected string) error {
+		return &SyntaxError{Pos: v.Pos, Message: fmt.Sprintf("invalid value '%s', expected %s", v.Raw, expected)}
+	}
+
+	switch f.Type {
+	case TypeNumber:
+		number, err := strconv.ParseFloat(v.Raw, 64)
+		if err != nil {
+			return invalid("a number")
+		}
+		v.Typed = number
+
+	case TypeDate:
+		date, ok := parseDate(v.Raw, now)
+		if !ok {
+			return invalid("a date (YYYY-MM-DD, RFC 3339, now, today or relative like -7d)")
+		}
+		v.Typed = date
+
+	case TypeOrdered:
+		for i, name := range f.Values {
+			if strings.EqualFold(v.Raw, name) {
+				v.Typed = i + 1
+				return nil
+			}
+		}
+		return invalid("one of " + strings.Join(f.Values, ", "))
+
+	default:
+		v.Typed = v.Raw
+	}
+	return nil
+}
+
+// parseDate parses an absolute or relative date. Relative dates are offsets from now in
+// minutes (m), hours (h), days (d) or weeks (w).
+func parseDate(raw string, now time.Time) (time.Time, bool) {
+	switch strings.ToLower(raw) {
+	case "now":
+		return now, true
+	case "today":
+		year, month, day := now.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), true
+	}
+
+	if m := relativeDate.FindStringSubmatch(raw); m != nil {
+		amount, err := strconv.Atoi(m[2])
+		if err != nil {
+			return time.Time{}, false
+		}
+		if m[1] == "-" {
+			amount = -amount
+		}
+		switch m[3] {
+		case "m":
+			return now.Add(time.Duration(amount) * time.Minute), true
+		case "h":
+			return now.Add(time.Duration(amount) * time.Hour), true
+		case "d":
+			return now.AddDate(0, 0, amount), true
+		case "w":
+			return now.AddDate(0, 0, 7*amount), true
+		}
+	}
+
+	if date, err := time.Parse("2006-01-02", raw); err == nil {
+		return date, true
+	}
+	if date, err := time.Parse(time.RFC3339, raw); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,85 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+
+var testSchema = Schema{
+	"status":   {Path: "status", Column: "status", Type: TypeString},
+	"priority": {Path: "priority_rank", Column: "priority_rank", Type: TypeOrdered, Values: []string{"low", "medium", "high"}},
+	"due_at":   {Path: "due_at", Column: "due_at", Type: TypeDate},
+	"labels": {
+		Path:       "labels",
+		Column:     "task_labels.label",
+		ExistsFrom: "task_labels WHERE task_labels.task_id = tasks.id",
+		Type:       TypeString,
+	},
+	"points": {Path: "custom_fields.points", Type: TypeNumber},
+}
+
+// parseValid parses and validates a filter expression against the test schema
+func parseValid(t *testing.T, input string) Node {
+	t.Helper()
+	node, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", input, err)
+	}
+	if err := testSchema.Validate(node, testNow); err != nil {
+		t.Fatalf("Validate(%q) failed: %v", input, err)
+	}
+	return node
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{"owner = bob", 0, "unknown field 'owner'"},
+		{"status = a and owner is null", 15, "unknown field 'owner'"},
+		{"status > open", 7, "field 'status' does not support >"},
+		{"priority = urgent", 11, "expected one of low, medium, high"},
+		{"priority in (low, urgent)", 18, "invalid value 'urgent'"},
+		{"points >= many", 10, "expected a number"},
+		{"due_at < tomorrow", 9, "expected a date"},
+		{"due_at < -7y", 9, "expected a date"},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+		}
+		err = testSchema.Validate(node, testNow)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok || syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Message, tt.message) {
+			t.Errorf("Validate(%q) error = %v, want %q at position %d", tt.input, err, tt.message, tt.pos)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"now", testNow},
+		{"TODAY", time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)},
+		{"-7d", testNow.AddDate(0, 0, -7)},
+		{"+2w", testNow.AddDate(0, 0, 14)},
+		{"-3h", testNow.Add(-3 * time.Hour)},
+		{"+15m", testNow.Add(15 * time.Minute)},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-31T08:00:00Z", time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := parseDate(tt.raw, testNow)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, %v, want %v", tt.raw, got, ok, tt.want)
+		}
+	}
+}
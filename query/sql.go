@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToSQL translates a validated expression into a SQL WHERE clause using ? placeholders,
+// returning the clause and its arguments. As in MongoDB, != and not in also match rows
+// where the field is NULL, and not matches rows where its expression is unknown because
+// of a NULL field.
+func (s Schema) ToSQL(node Node) (string, []interface{}, error) {
+	var args []interface{}
+	where, err := s.sql(node, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, args, nil
+}
+
+func (s Schema) sql(node Node, args *[]interface{}) (string, error) {
+	switch n := node.(type) {
+	case *And:
+		return s.sqlBinary("AND", n.Left, n.Right, args)
+	case *Or:
+		return s.sqlBinary("OR", n.Left, n.Right, args)
+	case *Not:
+		expr, err := s.sql(n.Expr, args)
+		if err != nil {
+			return "", err
+		}
+		// NOT of an unknown result is still unknown in SQL, whereas $nor matches it
+		return fmt.Sprintf("(CASE WHEN %s THEN 1 ELSE 0 END) = 0", expr), nil
+
+	case *Comparison:
+		field, err := s.sqlField(n.Field)
+		if err != nil {
+			return "", err
+		}
+		*args = append(*args, n.Value.Typed)
+		if field.ExistsFrom != "" {
+			if n.Op == "!=" {
+				return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s AND %s = ?)", field.ExistsFrom, field.Column), nil
+			}
+			return fmt.Sprintf("EXISTS (SELECT 1 FROM %s AND %s %s ?)", field.ExistsFrom, field.Column, n.Op), nil
+		}
+		if n.Op == "!=" {
+			return fmt.Sprintf("(%s <> ? OR %s IS NULL)", field.Column, field.Column), nil
+		}
+		return fmt.Sprintf("%s %s ?", field.Column, n.Op), nil
+
+	case *In:
+		field, err := s.sqlField(n.Field)
+		if err != nil {
+			return "", err
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(n.Values)), ", ")
+		for _, v := range n.Values {
+			*args = append(*args, v.Typed)
+		}
+		if field.ExistsFrom != "" {
+			exists := fmt.Sprintf("EXISTS (SELECT 1 FROM %s AND %s IN (%s))", field.ExistsFrom, field.Column, placeholders)
+			if n.Negated {
+				return "NOT " + exists, nil
+			}
+			return exists, nil
+		}
+		if n.Negated {
+			return fmt.Sprintf("(%s NOT IN (%s) OR %s IS NULL)", field.Column, placeholders, field.Column), nil
+		}
+		return fmt.Sprintf("%s IN (%s)", field.Column, placeholders), nil
+
+	case *Null:
+		field, err := s.sqlField(n.Field)
+		if err != nil {
+			return "", err
+		}
+		if field.ExistsFrom != "" {
+			exists := fmt.Sprintf("EXISTS (SELECT 1 FROM %s)", field.ExistsFrom)
+			if n.Negated {
+				return exists, nil
+			}
+			return "NOT " + exists, nil
+		}
+		if n.Negated {
+			return field.Column + " IS NOT NULL", nil
+		}
+		return field.Column + " IS NULL", nil
+	}
+	return "", fmt.Errorf("unsupported filter node %T", node)
+}
+
+func (s Schema) sqlBinary(op string, left, right Node, args *[]interface{}) (string, error) {
+	l, err := s.sql(left, args)
+	if err != nil {
+		return "", err
+	}
+	r, err := s.sql(right, args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("(%s %s %s)", l, op, r), nil
+}
+
+func (s Schema) sqlField(ref Ref) (Field, error) {
+	field, err := s.lookup(ref)
+	if err != nil {
+		return Field{}, err
+	}
+	if field.Column == "" {
+		return Field{}, &SyntaxError{Pos: ref.Pos, Message: fmt.Sprintf("field '%s' is not available in SQL", ref.Name)}
+	}
+	return field, nil
+}
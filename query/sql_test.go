@@ -0,0 +1,63 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToSQL(t *testing.T) {
+	today := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		where string
+		args  []interface{}
+	}{
+		{"status = open", "status = ?", []interface{}{"open"}},
+		{"status != open", "(status <> ? OR status IS NULL)", []interface{}{"open"}},
+		{"priority in (low, high)", "priority_rank IN (?, ?)", []interface{}{1, 3}},
+		{"priority not in (low)", "(priority_rank NOT IN (?) OR priority_rank IS NULL)", []interface{}{1}},
+		{"due_at is not null", "due_at IS NOT NULL", nil},
+		{
+			"labels = bug",
+			"EXISTS (SELECT 1 FROM task_labels WHERE task_labels.task_id = tasks.id AND task_labels.label = ?)",
+			[]interface{}{"bug"},
+		},
+		{
+			"labels != bug",
+			"NOT EXISTS (SELECT 1 FROM task_labels WHERE task_labels.task_id = tasks.id AND task_labels.label = ?)",
+			[]interface{}{"bug"},
+		},
+		{
+			"labels not in (a, b)",
+			"NOT EXISTS (SELECT 1 FROM task_labels WHERE task_labels.task_id = tasks.id AND task_labels.label IN (?, ?))",
+			[]interface{}{"a", "b"},
+		},
+		{"labels is null", "NOT EXISTS (SELECT 1 FROM task_labels WHERE task_labels.task_id = tasks.id)", nil},
+		{"not due_at > today", "(CASE WHEN due_at > ? THEN 1 ELSE 0 END) = 0", []interface{}{today}},
+		{
+			"status = a and (priority > low or due_at is null)",
+			"(status = ? AND (priority_rank > ? OR due_at IS NULL))",
+			[]interface{}{"a", 1},
+		},
+	}
+	for _, tt := range tests {
+		where, args, err := testSchema.ToSQL(parseValid(t, tt.input))
+		if err != nil {
+			t.Errorf("ToSQL(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if where != tt.where || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("ToSQL(%q) = %q %v, want %q %v", tt.input, where, args, tt.where, tt.args)
+		}
+	}
+}
+
+func TestToSQLUnavailableField(t *testing.T) {
+	_, _, err := testSchema.ToSQL(parseValid(t, "status = a or points > 1"))
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok || syntaxErr.Pos != 14 || !strings.Contains(syntaxErr.Message, "not available in SQL") {
+		t.Errorf("ToSQL error = %v, want field not available at position 14", err)
+	}
+}
@@ -0,0 +1,53 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Message types exchanged with clients and between instances
+const (
+	// MessageEvent carries a task event, in Data
+	MessageEvent = "event"
+	// MessagePresence announces that User is viewing the topic, or has left it
+	MessagePresence = "presence"
+	// MessageTyping announces that User is typing in Field of the topic
+	MessageTyping = "typing"
+	// Only exchanged with clients
+	MessageSubscribe   = "subscribe"
+	MessageUnsubscribe = "unsubscribe"
+	MessageSubscribed  = "subscribed"
+	MessagePing        = "ping"
+	MessagePong        = "pong"
+	MessageError       = "error"
+)
+
+// Presence states
+const (
+	PresenceViewing = "viewing"
+	PresenceLeft    = "left"
+)
+
+// Message is a message sent to or received from a client, and the unit published
+// through brokers
+type Message struct {
+	Type  string `json:"type" bson:"type"`
+	Topic string `json:"topic,omitempty" bson:"topic,omitempty"`
+	// Name of the task event of event messages
+	Event string `json:"event,omitempty" bson:"event,omitempty"`
+	User  string `json:"user,omitempty" bson:"user,omitempty"`
+	State string `json:"state,omitempty" bson:"state,omitempty"`
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+	// Users viewing the topic, in presence messages sent to clients
+	Users   []string        `json:"users,omitempty" bson:"-"`
+	Data    json.RawMessage `json:"data,omitempty" bson:"data,omitempty"`
+	Message string          `json:"message,omitempty" bson:"-"`
+}
+
+// Broker fans messages out to the hubs of all server instances
+type Broker interface {
+	// Publish sends the message to the subscribers of every instance, including this one
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe returns a channel receiving every published message until ctx is done
+	Subscribe(ctx context.Context) (<-chan Message, error)
+}
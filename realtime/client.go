@@ -0,0 +1,166 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait is the time allowed to write a message to the client
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed to read the next pong from the client
+	pongWait = 60 * time.Second
+	// pingPeriod is how often the client is pinged; it must be less than pongWait
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize is the largest message accepted from the client
+	maxMessageSize = 4096
+	// sendBuffer is the number of messages a client may fall behind by before it is
+	// disconnected
+	sendBuffer = 64
+)
+
+// Client is a WebSocket connection of a user
+type Client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	user      string
+	authorize func(topic string) error
+	// Topics the client is subscribed to, and whether its user is viewing them; guarded
+	// by hub.mu
+	subscriptions map[string]bool
+
+	outgoing  chan Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, user string, authorize func(topic string) error) *Client {
+	return &Client{
+		hub:           hub,
+		conn:          conn,
+		user:          user,
+		authorize:     authorize,
+		subscriptions: map[string]bool{},
+		outgoing:      make(chan Message, sendBuffer),
+		done:          make(chan struct{}),
+	}
+}
+
+// send queues a message for the client without blocking, disconnecting clients that
+// have fallen too far behind
+func (c *Client) send(msg Message) {
+	select {
+	case <-c.done:
+	case c.outgoing <- msg:
+	default:
+		go c.closeWith(websocket.ClosePolicyViolation, "too slow to receive messages")
+	}
+}
+
+// closeWith sends a close frame and closes the connection, ending run
+func (c *Client) closeWith(code int, text string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		deadline := time.Now().Add(writeWait)
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+		_ = c.conn.Close()
+	})
+}
+
+// run reads the client's messages until the connection is closed, while writing
+// messages sent to it from another goroutine
+func (c *Client) run() {
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writeLoop()
+	}()
+
+	c.readLoop()
+	c.closeWith(websocket.CloseNormalClosure, "")
+	<-writerDone
+}
+
+func (c *Client) readLoop() {
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg Message
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				c.send(Message{Type: MessageError, Message: "invalid message"})
+				continue
+			}
+			return
+		}
+		c.handle(msg)
+	}
+}
+
+func (c *Client) writeLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.outgoing:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.closeWith(websocket.CloseInternalServerErr, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.closeWith(websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// handle processes a message received from the client
+func (c *Client) handle(msg Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+	defer cancel()
+
+	switch msg.Type {
+	case MessagePing:
+		c.send(Message{Type: MessagePong})
+	case MessageSubscribe:
+		if err := c.authorize(msg.Topic); err != nil {
+			c.send(Message{Type: MessageError, Topic: msg.Topic, Message: err.Error()})
+			return
+		}
+		users := c.hub.subscribe(c, msg.Topic)
+		c.send(Message{Type: MessageSubscribed, Topic: msg.Topic, Users: users})
+	case MessageUnsubscribe:
+		c.hub.unsubscribe(ctx, c, msg.Topic)
+	case MessagePresence, MessageTyping:
+		if !c.hub.subscribed(c, msg.Topic) {
+			c.send(Message{Type: MessageError, Topic: msg.Topic, Message: "not subscribed to topic"})
+			return
+		}
+		if msg.Type == MessageTyping {
+			c.hub.publish(ctx, Message{Type: MessageTyping, Topic: msg.Topic, User: c.user, Field: msg.Field})
+			return
+		}
+		switch msg.State {
+		case PresenceViewing, PresenceLeft:
+			c.hub.setViewing(ctx, c, msg.Topic, msg.State == PresenceViewing)
+		default:
+			c.send(Message{Type: MessageError, Topic: msg.Topic, Message: "state must be viewing or left"})
+		}
+	default:
+		c.send(Message{Type: MessageError, Message: "unknown message type " + msg.Type})
+	}
+}
@@ -0,0 +1,325 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"taskify/models"
+)
+
+const (
+	// presenceTTL is how long a user is shown viewing a topic without a refresh, so that
+	// users of instances that stopped abruptly disappear
+	presenceTTL = time.Minute
+	// presenceRefresh is how often instances refresh the presence of their viewers
+	presenceRefresh = 20 * time.Second
+	// resubscribeDelay is the delay before subscribing again after the broker failed
+	resubscribeDelay = 5 * time.Second
+)
+
+// TaskTopic returns the topic of a task's events and signals
+func TaskTopic(taskID string) string {
+	return "task:" + taskID
+}
+
+// ProjectTopic returns the topic of the events of a project's tasks
+func ProjectTopic(project string) string {
+	return "project:" + project
+}
+
+// Hub connects the WebSocket clients of this instance to topics. Task events, presence
+// and typing signals go through the broker, so clients connected to any instance receive
+// them; presence is tracked by every hub from the signals it receives.
+type Hub struct {
+	broker Broker
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	topics  map[string]map[*Client]struct{}
+	// Users viewing each topic, with the time their presence expires
+	presence map[string]map[string]time.Time
+	closed   bool
+}
+
+// NewHub creates a hub publishing through the broker
+func NewHub(broker Broker) *Hub {
+	return &Hub{
+		broker:   broker,
+		clients:  map[*Client]struct{}{},
+		topics:   map[string]map[*Client]struct{}{},
+		presence: map[string]map[string]time.Time{},
+	}
+}
+
+// Run delivers the messages published through the broker to the subscribed clients and
+// maintains presence until ctx is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(presenceRefresh)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		messages, err := h.broker.Subscribe(ctx)
+		if err != nil {
+			log.Printf("Error: realtime broker subscription failed: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(resubscribeDelay):
+			}
+			continue
+		}
+
+	receive:
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					break receive
+				}
+				h.dispatch(msg)
+			case <-ticker.C:
+				h.refreshPresence(ctx)
+			}
+		}
+	}
+}
+
+// PublishTaskEvent sends a task event, encoded as data, to the subscribers of the task
+// and of its project
+func (h *Hub) PublishTaskEvent(ctx context.Context, event *models.TaskEvent, data []byte) {
+	topics := []string{TaskTopic(event.TaskID.Hex())}
+	if event.Task != nil && event.Task.Project != "" {
+		topics = append(topics, ProjectTopic(event.Task.Project))
+	}
+	for _, topic := range topics {
+		msg := Message{Type: MessageEvent, Topic: topic, Event: event.Event, User: event.Actor, Data: data}
+		if err := h.broker.Publish(ctx, msg); err != nil {
+			log.Printf("Error: failed to publish %s to %s: %v", event.Event, topic, err)
+		}
+	}
+}
+
+// Serve runs a client on the connection until it disconnects or the hub is closed.
+// authorize checks whether the user may subscribe to a topic.
+func (h *Hub) Serve(conn *websocket.Conn, user string, authorize func(topic string) error) {
+	client := newClient(h, conn, user, authorize)
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		client.closeWith(websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	client.run()
+	h.remove(client)
+}
+
+// Close disconnects all clients. It is called when the server shuts down, as WebSocket
+// connections are not tracked by the HTTP server.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.closeWith(websocket.CloseGoingAway, "server is shutting down")
+	}
+}
+
+// subscribe adds the client to the topic and returns the users viewing it
+func (h *Hub) subscribe(client *Client, topic string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*Client]struct{}{}
+	}
+	h.topics[topic][client] = struct{}{}
+	client.subscriptions[topic] = false
+	return h.viewers(topic)
+}
+
+// subscribed reports whether the client is subscribed to the topic
+func (h *Hub) subscribed(client *Client, topic string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := client.subscriptions[topic]
+	return ok
+}
+
+// setViewing records whether the client's user is viewing a topic it is subscribed to
+// and announces the change. The user only leaves once none of their clients on this
+// instance is viewing the topic.
+func (h *Hub) setViewing(ctx context.Context, client *Client, topic string, viewing bool) {
+	h.mu.Lock()
+	client.subscriptions[topic] = viewing
+	announce := viewing || !h.viewingLocally(client.user, topic)
+	h.mu.Unlock()
+
+	if announce {
+		state := PresenceLeft
+		if viewing {
+			state = PresenceViewing
+		}
+		h.publish(ctx, Message{Type: MessagePresence, Topic: topic, User: client.user, State: state})
+	}
+}
+
+// unsubscribe removes the client from the topic, leaving it if it was viewing it
+func (h *Hub) unsubscribe(ctx context.Context, client *Client, topic string) {
+	h.mu.Lock()
+	viewing := client.subscriptions[topic]
+	delete(client.subscriptions, topic)
+	delete(h.topics[topic], client)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+	h.mu.Unlock()
+
+	if viewing {
+		h.setViewing(ctx, client, topic, false)
+	}
+}
+
+// remove unsubscribes a disconnected client from all its topics
+func (h *Hub) remove(client *Client) {
+	h.mu.Lock()
+	delete(h.clients, client)
+	topics := make([]string, 0, len(client.subscriptions))
+	for topic := range client.subscriptions {
+		topics = append(topics, topic)
+	}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, topic := range topics {
+		h.unsubscribe(ctx, client, topic)
+	}
+}
+
+// viewingLocally reports whether a client of the user subscribed to the topic is viewing
+// it. h.mu must be held.
+func (h *Hub) viewingLocally(user, topic string) bool {
+	for client := range h.topics[topic] {
+		if client.user == user && client.subscriptions[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// viewers returns the users viewing a topic, sorted. h.mu must be held.
+func (h *Hub) viewers(topic string) []string {
+	users := make([]string, 0, len(h.presence[topic]))
+	for user := range h.presence[topic] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// dispatch sends a published message to the clients subscribed to its topic
+func (h *Hub) dispatch(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if msg.Type == MessagePresence {
+		if !h.updatePresence(msg.Topic, msg.User, msg.State) {
+			return
+		}
+		msg = Message{Type: MessagePresence, Topic: msg.Topic, Users: h.viewers(msg.Topic)}
+	}
+	for client := range h.topics[msg.Topic] {
+		// Clients know what their own user is typing
+		if msg.Type == MessageTyping && client.user == msg.User {
+			continue
+		}
+		client.send(msg)
+	}
+}
+
+// updatePresence records a presence signal and reports whether the viewers of the topic
+// changed. h.mu must be held.
+func (h *Hub) updatePresence(topic, user, state string) bool {
+	viewers := h.presence[topic]
+	_, present := viewers[user]
+	if state != PresenceViewing {
+		if !present {
+			return false
+		}
+		delete(viewers, user)
+		if len(viewers) == 0 {
+			delete(h.presence, topic)
+		}
+		return true
+	}
+
+	if viewers == nil {
+		viewers = map[string]time.Time{}
+		h.presence[topic] = viewers
+	}
+	viewers[user] = time.Now().Add(presenceTTL)
+	return !present
+}
+
+// refreshPresence announces again the users viewing topics on this instance, and
+// removes the presence of users that were not refreshed in time
+func (h *Hub) refreshPresence(ctx context.Context) {
+	h.mu.Lock()
+	var refresh []Message
+	seen := map[[2]string]bool{}
+	for topic, clients := range h.topics {
+		for client := range clients {
+			key := [2]string{topic, client.user}
+			if client.subscriptions[topic] && !seen[key] {
+				seen[key] = true
+				refresh = append(refresh, Message{Type: MessagePresence, Topic: topic, User: client.user, State: PresenceViewing})
+			}
+		}
+	}
+
+	now := time.Now()
+	for topic, viewers := range h.presence {
+		changed := false
+		for user, expires := range viewers {
+			if now.After(expires) {
+				delete(viewers, user)
+				changed = true
+			}
+		}
+		if len(viewers) == 0 {
+			delete(h.presence, topic)
+		}
+		if changed {
+			msg := Message{Type: MessagePresence, Topic: topic, Users: h.viewers(topic)}
+			for client := range h.topics[topic] {
+				client.send(msg)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	// Publish from another goroutine, as the in-process broker delivers to Run
+	go func() {
+		for _, msg := range refresh {
+			h.publish(ctx, msg)
+		}
+	}()
+}
+
+// publish sends a message through the broker, logging failures
+func (h *Hub) publish(ctx context.Context, msg Message) {
+	if err := h.broker.Publish(ctx, msg); err != nil {
+		log.Printf("Error: failed to publish %s to %s: %v", msg.Type, msg.Topic, err)
+	}
+}
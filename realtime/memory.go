@@ -0,0 +1,48 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker is a Broker for a single server instance, delivering messages in process
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan Message]struct{}
+}
+
+// NewMemoryBroker creates an in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: map[chan Message]struct{}{}}
+}
+
+// Publish delivers the message to every subscriber, waiting for ones that are behind
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber until ctx is done
+func (b *MemoryBroker) Subscribe(ctx context.Context) (<-chan Message, error) {
+	ch := make(chan Message, 256)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,65 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoBroker is a Broker for several server instances sharing a MongoDB replica set.
+// Messages are inserted into a collection, whose change stream every instance watches;
+// a TTL index removes them shortly after.
+type MongoBroker struct {
+	collection *mongo.Collection
+}
+
+// NewMongoBroker creates a broker publishing through the collection
+func NewMongoBroker(collection *mongo.Collection) *MongoBroker {
+	return &MongoBroker{collection: collection}
+}
+
+// Publish inserts the message for the instances to pick up
+func (b *MongoBroker) Publish(ctx context.Context, msg Message) error {
+	_, err := b.collection.InsertOne(ctx, bson.M{"message": msg, "created_at": time.Now()})
+	return err
+}
+
+// Subscribe watches the collection for messages inserted from now on. The channel is
+// closed when ctx is done or the change stream fails.
+func (b *MongoBroker) Subscribe(ctx context.Context) (<-chan Message, error) {
+	stream, err := b.collection.Watch(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": "insert"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Message, 256)
+	go func() {
+		defer close(ch)
+		defer stream.Close(context.Background())
+		for stream.Next(ctx) {
+			var change struct {
+				FullDocument struct {
+					Message Message `bson:"message"`
+				} `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Printf("Error: failed to decode realtime message: %v", err)
+				continue
+			}
+			select {
+			case ch <- change.FullDocument.Message:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("Error: realtime change stream failed: %v", err)
+		}
+	}()
+	return ch, nil
+}
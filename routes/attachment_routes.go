@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"taskify/controllers"
+)
+
+// RegisterAttachmentRoutes registers all task attachment related routes
+func RegisterAttachmentRoutes(rg *gin.RouterGroup) {
+	attachments := rg.Group("/tasks/:id/attachments")
+	{
+		attachments.GET("", controllers.GetAttachments)
+		attachments.POST("", controllers.UploadAttachment)
+		attachments.GET("/:attachmentId", controllers.DownloadAttachment)
+		attachments.DELETE("/:attachmentId", controllers.DeleteAttachment)
+	}
+}
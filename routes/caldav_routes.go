@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+
+	"taskify/controllers"
+	"taskify/middleware"
+)
+
+// caldavMethods lists the HTTP and WebDAV methods CalDAV clients use
+var caldavMethods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "REPORT", "MKCOL", "COPY", "MOVE",
+}
+
+// RegisterCalDAVRoutes registers the CalDAV server. CalDAV clients authenticate with the
+// user's username and password, and changes are authorized per task action by the
+// handler rather than by route.
+func RegisterCalDAVRoutes(r *gin.Engine, enforcer *casbin.Enforcer) {
+	caldav := r.Group("", middleware.BasicAuthMiddleware(), middleware.WithEnforcer(enforcer))
+	for _, method := range caldavMethods {
+		caldav.Handle(method, "/caldav/*path", controllers.CalDAV)
+	}
+	caldav.GET("/.well-known/caldav", controllers.CalDAV)
+	caldav.Handle("PROPFIND", "/.well-known/caldav", controllers.CalDAV)
+}
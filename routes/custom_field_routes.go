@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"taskify/controllers"
+)
+
+// RegisterCustomFieldRoutes registers all custom field definition routes
+func RegisterCustomFieldRoutes(rg *gin.RouterGroup) {
+	fields := rg.Group("/custom-fields")
+	{
+		fields.GET("", controllers.GetCustomFields)
+		fields.POST("", controllers.CreateCustomField)         // Admin only, see config/policy.csv
+		fields.PUT("/:key", controllers.UpdateCustomField)     // Admin only, see config/policy.csv
+		fields.DELETE("/:key", controllers.ArchiveCustomField) // Admin only, see config/policy.csv
+	}
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"taskify/controllers"
+)
+
+// RegisterEventRoutes registers the stream of task events
+func RegisterEventRoutes(rg *gin.RouterGroup) {
+	rg.GET("/events", controllers.StreamEvents)
+}
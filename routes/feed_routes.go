@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+
+	"taskify/controllers"
+	"taskify/middleware"
+)
+
+// RegisterFeedRoutes registers the routes managing calendar feeds
+func RegisterFeedRoutes(rg *gin.RouterGroup) {
+	feeds := rg.Group("/feeds")
+	{
+		feeds.GET("", controllers.GetFeedTokens)
+		feeds.POST("", controllers.CreateFeedToken)
+		feeds.DELETE("/:id", controllers.DeleteFeedToken)
+	}
+}
+
+// RegisterCalendarRoutes registers the calendar feeds themselves. These are public
+// endpoints authenticated by the secret token in the URL, as calendar clients cannot
+// send bearer tokens. The handler checks the owner's role itself.
+func RegisterCalendarRoutes(r gin.IRouter, enforcer *casbin.Enforcer) {
+	r.GET("/api/v1/calendar/:token/tasks.ics", middleware.WithEnforcer(enforcer), controllers.GetTaskFeed)
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"taskify/controllers"
+)
+
+// RegisterRealtimeRoutes registers the WebSocket endpoint for real-time collaboration
+func RegisterRealtimeRoutes(rg *gin.RouterGroup) {
+	rg.GET("/ws", controllers.ServeWebSocket)
+}
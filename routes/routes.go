@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"taskify/middleware"
 	"net/http"
 	"time"
@@ -14,17 +15,30 @@ var startTime = time.Now()
 func RegisterRoutes(r *gin.Engine, enforcer *casbin.Enforcer) {
 	// Health check route
 	r.GET("/health", healthCheck)
+	// Prometheus metrics, such as the outbox lag
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Public routes
 	RegisterAuthRoutes(r)
+	RegisterCalendarRoutes(r, enforcer)
+	RegisterCalDAVRoutes(r, enforcer)
 
 	// Protected API routes
 	api := r.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware())
 	api.Use(middleware.PermissionMiddleware(enforcer))
+	api.Use(middleware.IdempotencyMiddleware())
 
 	// Register protected routes under /api/v1
 	RegisterTaskRoutes(api)
+	RegisterAttachmentRoutes(api)
+	RegisterTrashRoutes(api)
+	RegisterWorkflowRoutes(api)
+	RegisterCustomFieldRoutes(api)
+	RegisterFeedRoutes(api)
+	RegisterWebhookRoutes(api)
+	RegisterEventRoutes(api)
+	RegisterRealtimeRoutes(api)
 }
 
 // Health check endpoint
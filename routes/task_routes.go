@@ -11,8 +11,19 @@ func RegisterTaskRoutes(rg *gin.RouterGroup) {
 	{
 		tasks.GET("", controllers.GetTasks)
 		tasks.POST("", controllers.CreateTask)
+		tasks.GET("/export", controllers.ExportTasks)
+		tasks.POST("/bulk", controllers.BulkTasks)
+		tasks.POST("/bulk/update", controllers.BulkUpdateTasks)
+		tasks.POST("/import", controllers.ImportTasks)
+		tasks.POST("/import/:source", controllers.ImportTrackerTasks)
 		tasks.GET("/:id", controllers.GetTask)
 		tasks.PUT("/:id", controllers.UpdateTask)
+		tasks.PATCH("/:id", controllers.PatchTask)
 		tasks.DELETE("/:id", controllers.DeleteTask)
+		tasks.GET("/:id/activity", controllers.GetTaskActivity)
+		tasks.GET("/:id/comments", controllers.GetComments)
+		tasks.POST("/:id/comments", controllers.CreateComment)
+		tasks.GET("/:id/recurrence/preview", controllers.PreviewRecurrence)
+		tasks.POST("/:id/recurrence/stop", controllers.StopRecurrence)
 	}
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"taskify/controllers"
+)
+
+// RegisterTrashRoutes registers all trash bin related routes
+func RegisterTrashRoutes(rg *gin.RouterGroup) {
+	trash := rg.Group("/trash")
+	{
+		trash.GET("", controllers.GetTrash)
+		trash.DELETE("/:id", controllers.PurgeTask) // Admin only, see config/policy.csv
+	}
+	rg.POST("/tasks/:id/restore", controllers.RestoreTask)
+}
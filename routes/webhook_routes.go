@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"taskify/controllers"
+)
+
+// RegisterWebhookRoutes registers the routes managing webhook subscriptions, which are
+// admin only, see config/policy.csv
+func RegisterWebhookRoutes(rg *gin.RouterGroup) {
+	webhooks := rg.Group("/webhooks")
+	{
+		webhooks.GET("", controllers.GetWebhooks)
+		webhooks.POST("", controllers.CreateWebhook)
+		webhooks.GET("/:id", controllers.GetWebhook)
+		webhooks.PUT("/:id", controllers.UpdateWebhook)
+		webhooks.DELETE("/:id", controllers.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook)
+	}
+}
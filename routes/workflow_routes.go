@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"taskify/controllers"
+)
+
+// RegisterWorkflowRoutes registers all workflow related routes
+func RegisterWorkflowRoutes(rg *gin.RouterGroup) {
+	rg.GET("/workflows/default", controllers.GetDefaultWorkflow)
+
+	projects := rg.Group("/projects/:project")
+	{
+		projects.GET("/workflow", controllers.GetProjectWorkflow)
+		projects.PUT("/workflow", controllers.SetProjectWorkflow)       // Admin only, see config/policy.csv
+		projects.DELETE("/workflow", controllers.DeleteProjectWorkflow) // Admin only, see config/policy.csv
+	}
+}
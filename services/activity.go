@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+	"taskify/events"
+	"taskify/models"
+)
+
+// RecordActivity appends an entry to a task's history. Entries already recorded for the
+// same event are skipped, as events may be relayed more than once.
+func RecordActivity(ctx context.Context, activity *models.Activity) error {
+	result, err := config.DB.Collection("activities").InsertOne(ctx, activity)
+	if mongo.IsDuplicateKeyError(err) && activity.EventID != "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("recording %s activity for task %s: %w", activity.Action, activity.TaskID.Hex(), err)
+	}
+	activity.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// recordEventActivity records the history entries of a task event. Assignee changes are
+// recorded as a separate assignment entry.
+func recordEventActivity(ctx context.Context, event events.Event) error {
+	var activities []*models.Activity
+	switch e := event.(type) {
+	case *events.TaskCreated:
+		activities = taskChangeActivities(e.Task.ID, models.ActivityCreated, e.Actor, e.Changes())
+	case *events.TaskUpdated:
+		activities = taskChangeActivities(e.Task.ID, models.ActivityUpdated, e.Actor, e.Changes)
+	case *events.TaskDeleted:
+		activities = append(activities, models.NewActivity(e.Task.ID, models.ActivityDeleted, e.Actor, nil))
+	case *events.TaskRestored:
+		activities = append(activities, models.NewActivity(e.Task.ID, models.ActivityRestored, e.Actor, nil))
+	case *events.TaskPurged:
+		activities = append(activities, models.NewActivity(e.TaskID, models.ActivityPurged, e.Actor, nil))
+	case *events.TaskCommented:
+		activity := models.NewActivity(e.Task.ID, models.ActivityCommented, e.Actor, nil)
+		activity.CommentID = e.Comment.ID
+		activities = append(activities, activity)
+	}
+
+	for _, activity := range activities {
+		activity.EventID = event.EventID()
+		activity.CreatedAt = event.OccurredAt()
+		if err := RecordActivity(ctx, activity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskChangeActivities returns the entries for a creation or update of a task, with
+// assignee changes split into an assignment entry
+func taskChangeActivities(taskID primitive.ObjectID, action, actor string, changes []models.FieldChange) []*models.Activity {
+	var fields, assignment []models.FieldChange
+	for _, change := range changes {
+		if change.Field == "assignee" {
+			assignment = append(assignment, change)
+			continue
+		}
+		fields = append(fields, change)
+	}
+
+	var activities []*models.Activity
+	if len(fields) > 0 || action == models.ActivityCreated {
+		activities = append(activities, models.NewActivity(taskID, action, actor, fields))
+	}
+	if len(assignment) > 0 {
+		activities = append(activities, models.NewActivity(taskID, models.ActivityAssigned, actor, assignment))
+	}
+	return activities
+}
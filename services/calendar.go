@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskify/errors"
+	"taskify/ical"
+	"taskify/models"
+)
+
+// Calendar components tasks can be rendered as
+const (
+	// CalendarEvents renders tasks as events on their due date, which every calendar shows
+	CalendarEvents = "VEVENT"
+	// CalendarTodos renders tasks as to-dos due on their due date
+	CalendarTodos = "VTODO"
+)
+
+// calendarPriorities maps priorities to iCalendar priorities, where 1 is the highest
+var calendarPriorities = map[string]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityMedium: 5,
+	models.PriorityLow:    9,
+}
+
+// todoStatuses maps status categories to the statuses of to-dos
+var todoStatuses = map[string]string{
+	models.CategoryTodo:  "NEEDS-ACTION",
+	models.CategoryDoing: "IN-PROCESS",
+	models.CategoryDone:  "COMPLETED",
+}
+
+// CalendarOptions control how tasks are rendered as a calendar
+type CalendarOptions struct {
+	// Name shown for the calendar by clients
+	Name string
+	// iCalendar METHOD of the calendar, e.g. PUBLISH for feeds; empty for CalDAV objects
+	Method string
+	// CalendarEvents or CalendarTodos
+	Component string
+	// Details adds the status, priority and link to descriptions, for clients that do
+	// not show them otherwise. Descriptions edited by clients must not include them.
+	Details bool
+	// TaskURL returns the link back to a task
+	TaskURL func(task *models.Task) string
+}
+
+// WriteCalendar writes the tasks as an iCalendar object. Events are only written for
+// tasks with due dates.
+func WriteCalendar(ctx context.Context, w io.Writer, tasks []*models.Task, opts CalendarOptions) error {
+	cal := ical.NewWriter(w)
+	cal.Begin("VCALENDAR")
+	cal.Line("VERSION", "2.0")
+	cal.Line("PRODID", "-//Taskify//Tasks//EN")
+	cal.Line("CALSCALE", "GREGORIAN")
+	if opts.Method != "" {
+		cal.Line("METHOD", opts.Method)
+	}
+	if opts.Name != "" {
+		cal.Text("X-WR-CALNAME", opts.Name)
+	}
+
+	workflows := map[string]*models.Workflow{}
+	for _, task := range tasks {
+		if task.DueAt == nil && opts.Component != CalendarTodos {
+			continue
+		}
+		workflow, ok := workflows[task.Project]
+		if !ok {
+			var err error
+			if workflow, err = WorkflowFor(ctx, task.Project); err != nil {
+				return errors.NewDatabaseError(err)
+			}
+			workflows[task.Project] = workflow
+		}
+		writeTaskComponent(cal, task, workflow.Category(task.Status), opts)
+	}
+
+	cal.End("VCALENDAR")
+	return cal.Err()
+}
+
+// writeTaskComponent writes a task as an event or to-do. All-day tasks, due at midnight
+// UTC, are written with DATE values.
+func writeTaskComponent(cal *ical.Writer, task *models.Task, category string, opts CalendarOptions) {
+	cal.Begin(opts.Component)
+	cal.Text("UID", task.CalendarUID())
+	cal.Line("DTSTAMP", ical.DateTime(task.UpdatedAt))
+	cal.Line("CREATED", ical.DateTime(task.CreatedAt))
+	cal.Line("LAST-MODIFIED", ical.DateTime(task.UpdatedAt))
+	if task.Version > 0 {
+		cal.Line("SEQUENCE", strconv.FormatInt(task.Version-1, 10))
+	}
+	cal.Text("SUMMARY", task.Title)
+
+	var url string
+	if opts.TaskURL != nil {
+		url = opts.TaskURL(task)
+	}
+	description := task.Description
+	if opts.Details {
+		description = fmt.Sprintf("Status: %s\nPriority: %s", task.Status, task.Priority)
+		if task.Description != "" {
+			description += "\n\n" + task.Description
+		}
+		if url != "" {
+			description += "\n\n" + url
+		}
+	}
+	if description != "" {
+		cal.Text("DESCRIPTION", description)
+	}
+	if url != "" {
+		cal.Line("URL", url)
+	}
+
+	var due time.Time
+	allDay := false
+	if task.DueAt != nil {
+		due = *task.DueAt
+		allDay = due.UTC().Equal(due.UTC().Truncate(24 * time.Hour))
+	}
+	switch opts.Component {
+	case CalendarTodos:
+		if task.DueAt != nil && allDay {
+			cal.Line("DUE;VALUE=DATE", ical.Date(due))
+		} else if task.DueAt != nil {
+			cal.Line("DUE", ical.DateTime(due))
+		}
+		if status, ok := todoStatuses[category]; ok {
+			cal.Line("STATUS", status)
+		}
+	default:
+		if allDay {
+			cal.Line("DTSTART;VALUE=DATE", ical.Date(due))
+		} else {
+			cal.Line("DTSTART", ical.DateTime(due))
+		}
+		cal.Line("STATUS", "CONFIRMED")
+		// Due dates are reminders, not busy time
+		cal.Line("TRANSP", "TRANSPARENT")
+	}
+
+	if priority, ok := calendarPriorities[task.Priority]; ok {
+		cal.Line("PRIORITY", strconv.Itoa(priority))
+	}
+	if len(task.Labels) > 0 {
+		labels := make([]string, len(task.Labels))
+		for i, label := range task.Labels {
+			labels[i] = ical.Escape(label)
+		}
+		cal.Line("CATEGORIES", strings.Join(labels, ","))
+	}
+	cal.End(opts.Component)
+}
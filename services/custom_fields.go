@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+)
+
+// CustomFieldPrefix marks custom fields in query parameters, e.g. cf.story_points=3
+const CustomFieldPrefix = "cf."
+
+// CustomFields loads all custom field definitions keyed by field key
+func CustomFields(ctx context.Context) (map[string]*models.CustomField, error) {
+	cursor, err := config.DB.Collection("custom_fields").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []*models.CustomField
+	if err := cursor.All(ctx, &fields); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+	return byKey, nil
+}
+
+// ApplyCustomFields validates the given values against their definitions and writes them
+// onto the task. A nil value clears the field. When creating a task, every required field
+// must be given a value.
+func ApplyCustomFields(ctx context.Context, task *models.Task, values map[string]interface{}, creating bool) error {
+	if len(values) == 0 && !creating {
+		return nil
+	}
+
+	fields, err := CustomFields(ctx)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return applyCustomFields(ctx, fields, task, values, creating)
+}
+
+// ReplaceCustomFields replaces all custom field values of the task with the given ones.
+// Only the values the request changes are validated: values equal to the stored ones are
+// kept as they are, so that editing a task does not fail because an enum option was
+// removed or a field was made required since its values were set. Stored values of
+// archived or deleted fields cannot be changed: given values for them are ignored and the
+// stored ones are kept.
+func ReplaceCustomFields(ctx context.Context, task *models.Task, values map[string]interface{}) error {
+	fields, err := CustomFields(ctx)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	changed := map[string]interface{}{}
+	for key := range task.CustomFields {
+		if field, ok := fields[key]; ok && !field.Archived {
+			if _, given := values[key]; !given {
+				changed[key] = nil
+			}
+		}
+	}
+	for key, value := range values {
+		stored, ok := task.CustomFields[key]
+		if field, defined := fields[key]; ok && (!defined || field.Archived) {
+			continue
+		}
+		if ok && sameFieldValue(stored, value) {
+			continue
+		}
+		changed[key] = value
+	}
+	return applyCustomFields(ctx, fields, task, changed, false)
+}
+
+// sameFieldValue reports whether a given custom field value is the stored one, as
+// rendered in task responses
+func sameFieldValue(stored, given interface{}) bool {
+	storedJSON, err := json.Marshal(stored)
+	if err != nil {
+		return false
+	}
+	givenJSON, err := json.Marshal(given)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(storedJSON, givenJSON)
+}
+
+func applyCustomFields(ctx context.Context, fields map[string]*models.CustomField, task *models.Task, values map[string]interface{}, creating bool) error {
+	for key, value := range values {
+		field, ok := fields[key]
+		if !ok || field.Archived {
+			return errors.NewInvalidInput("Unknown custom field " + key)
+		}
+
+		if value == nil {
+			if field.Required {
+				return errors.NewInvalidInput("Custom field " + key + " is required")
+			}
+			delete(task.CustomFields, key)
+			continue
+		}
+
+		normalized, err := field.Normalize(value)
+		if err != nil {
+			return errors.NewInvalidInput(err.Error())
+		}
+		if field.Type == models.FieldTypeUser {
+			if err := EnsureUserExists(ctx, normalized.(string)); err != nil {
+				return err
+			}
+		}
+
+		if task.CustomFields == nil {
+			task.CustomFields = map[string]interface{}{}
+		}
+		task.CustomFields[key] = normalized
+	}
+
+	if creating {
+		for key, field := range fields {
+			if field.Required && !field.Archived && task.CustomFields[key] == nil {
+				return errors.NewInvalidInput("Custom field " + key + " is required")
+			}
+		}
+	}
+	if len(task.CustomFields) == 0 {
+		task.CustomFields = nil
+	}
+	return nil
+}
+
+// CustomFieldFilter translates cf.<key>=value query parameters into a task filter
+func CustomFieldFilter(ctx context.Context, query map[string][]string) (bson.M, error) {
+	filter := bson.M{}
+	var fields map[string]*models.CustomField
+
+	for param, values := range query {
+		if !strings.HasPrefix(param, CustomFieldPrefix) || len(values) == 0 {
+			continue
+		}
+
+		if fields == nil {
+			var err error
+			if fields, err = CustomFields(ctx); err != nil {
+				return nil, errors.NewDatabaseError(err)
+			}
+		}
+
+		key := strings.TrimPrefix(param, CustomFieldPrefix)
+		field, ok := fields[key]
+		if !ok {
+			return nil, errors.NewInvalidInput("Unknown custom field " + key)
+		}
+		value, err := field.ParseQueryValue(values[0])
+		if err != nil {
+			return nil, errors.NewInvalidInput(err.Error())
+		}
+		filter["custom_fields."+key] = value
+	}
+	return filter, nil
+}
+
+// CustomFieldSortKey maps a cf.<key> sort parameter to the document path of the field
+func CustomFieldSortKey(ctx context.Context, sort string) (string, error) {
+	key := strings.TrimPrefix(sort, CustomFieldPrefix)
+	fields, err := CustomFields(ctx)
+	if err != nil {
+		return "", errors.NewDatabaseError(err)
+	}
+	if _, ok := fields[key]; !ok {
+		return "", errors.NewInvalidInput("Unknown custom field " + key)
+	}
+	return "custom_fields." + key, nil
+}
+
+// EnsureUserExists returns an invalid input error if no user has the given username
+func EnsureUserExists(ctx context.Context, username string) error {
+	count, err := config.DB.Collection("users").CountDocuments(ctx, bson.M{"username": username}, options.Count().SetLimit(1))
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if count == 0 {
+		return errors.NewInvalidInput("User " + username + " does not exist")
+	}
+	return nil
+}
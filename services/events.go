@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"taskify/config"
+	"taskify/events"
+	"taskify/models"
+)
+
+// Events is the bus the outbox relay publishes domain events on once the changes they
+// describe have been committed
+var Events = events.NewBus()
+
+// SubscribeEventHandlers registers the handlers of domain events. The task history,
+// the event stream and the webhook deliveries are updated synchronously, so that the
+// relay retries an event until all of them have handled it; they discard the events
+// they already handled, the event stream as long as they are in its buffer. Realtime
+// messages are sent in the background.
+func SubscribeEventHandlers() {
+	Events.Subscribe("activity", recordEventActivity)
+	Events.Subscribe("stream", streamTaskEvent)
+	Events.Subscribe("webhooks", enqueueTaskEventWebhooks)
+	Events.SubscribeAsync("realtime", publishRealtimeTaskEvent)
+}
+
+// NewTaskEvent returns the task event sent to webhooks and event streams for a domain
+// event, and its JSON encoding. ok is false for events that are not about tasks.
+func NewTaskEvent(event events.Event) (taskEvent *models.TaskEvent, body []byte, ok bool, err error) {
+	taskEvent = &models.TaskEvent{
+		ID:        event.EventID(),
+		Event:     event.EventName(),
+		CreatedAt: event.OccurredAt(),
+	}
+	switch e := event.(type) {
+	case *events.TaskCreated:
+		taskEvent.Actor, taskEvent.Task, taskEvent.Changes = e.Actor, e.Task, e.Changes()
+	case *events.TaskUpdated:
+		taskEvent.Actor, taskEvent.Task, taskEvent.Changes = e.Actor, e.Task, e.Changes
+	case *events.TaskDeleted:
+		taskEvent.Actor, taskEvent.Task = e.Actor, e.Task
+	case *events.TaskRestored:
+		taskEvent.Actor, taskEvent.Task = e.Actor, e.Task
+	case *events.TaskPurged:
+		taskEvent.Actor, taskEvent.TaskID = e.Actor, e.TaskID
+	case *events.TaskCommented:
+		taskEvent.Actor, taskEvent.Task, taskEvent.CommentID = e.Actor, e.Task, e.Comment.ID
+	default:
+		return nil, nil, false, nil
+	}
+	if taskEvent.Task != nil {
+		taskEvent.TaskID = taskEvent.Task.ID
+	}
+
+	body, err = json.Marshal(taskEvent)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return taskEvent, body, true, nil
+}
+
+// streamTaskEvent sends task events to the clients of the event stream
+func streamTaskEvent(ctx context.Context, event events.Event) error {
+	taskEvent, body, ok, err := NewTaskEvent(event)
+	if !ok {
+		return err
+	}
+	TaskStream.Publish(taskEvent, body)
+	return nil
+}
+
+// publishRealtimeTaskEvent sends task events to the WebSocket clients subscribed to the
+// task or its project
+func publishRealtimeTaskEvent(ctx context.Context, event events.Event) error {
+	if config.Realtime == nil {
+		return nil
+	}
+	taskEvent, body, ok, err := NewTaskEvent(event)
+	if !ok {
+		return err
+	}
+	config.Realtime.PublishTaskEvent(ctx, taskEvent, body)
+	return nil
+}
+
+// enqueueTaskEventWebhooks queues the deliveries of task events to webhooks
+func enqueueTaskEventWebhooks(ctx context.Context, event events.Event) error {
+	taskEvent, body, ok, err := NewTaskEvent(event)
+	if !ok {
+		return err
+	}
+	return EnqueueWebhooks(ctx, taskEvent, body)
+}
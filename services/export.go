@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/errors"
+	"taskify/models"
+)
+
+// taskExportColumns are the task fields exported by default, in column order
+var taskExportColumns = []string{
+	"id", "title", "description", "status", "priority", "project", "assignee", "parent_id",
+	"external_id", "due_at", "labels", "recurrence", "created_at", "updated_at",
+}
+
+// ParseExportColumns parses the comma-separated columns of a task export and returns them
+// with the projection loading them. Columns are task fields, as accepted by fields= on
+// task reads, or custom fields as cf.<key>. By default all task fields are exported,
+// followed by every custom field that is not archived.
+func ParseExportColumns(ctx context.Context, param string) ([]string, bson.M, error) {
+	fields, err := CustomFields(ctx)
+	if err != nil {
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	columns := splitList(param)
+	if len(columns) == 0 {
+		columns = append(columns, taskExportColumns...)
+		var keys []string
+		for key, field := range fields {
+			if !field.Archived {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			columns = append(columns, CustomFieldPrefix+key)
+		}
+	}
+
+	projection := bson.M{}
+	for _, column := range columns {
+		if key := strings.TrimPrefix(column, CustomFieldPrefix); key != column {
+			if _, ok := fields[key]; !ok {
+				return nil, nil, errors.NewInvalidInput("Unknown custom field " + key)
+			}
+			projection["custom_fields."+key] = 1
+			continue
+		}
+		path, ok := taskFieldPaths[column]
+		if !ok {
+			return nil, nil, errors.NewInvalidInput("Unknown column '" + column + "'. Allowed: " + strings.Join(sortedKeys(taskFieldPaths), ", ") + ", " + CustomFieldPrefix + "<key>")
+		}
+		projection[path] = 1
+	}
+	pruneProjection(projection)
+	return columns, projection, nil
+}
+
+// ExportValue returns the value of an export column of a task. IDs are returned as hex
+// strings and recurrences as their rule; missing values are nil.
+func ExportValue(task *models.Task, column string) interface{} {
+	switch column {
+	case "id":
+		return task.ID.Hex()
+	case "title":
+		return task.Title
+	case "description":
+		return task.Description
+	case "status":
+		return task.Status
+	case "priority":
+		return task.Priority
+	case "project":
+		return task.Project
+	case "assignee":
+		return task.Assignee
+	case "parent_id":
+		if task.ParentID == nil {
+			return nil
+		}
+		return task.ParentID.Hex()
+	case "external_id":
+		return task.ExternalID
+	case "due_at":
+		if task.DueAt == nil {
+			return nil
+		}
+		return *task.DueAt
+	case "labels":
+		return task.Labels
+	case "recurrence":
+		if task.Recurrence == nil {
+			return nil
+		}
+		return task.Recurrence.Rule
+	case "custom_fields":
+		return task.CustomFields
+	case "created_at":
+		return task.CreatedAt
+	case "updated_at":
+		return task.UpdatedAt
+	case "deleted_at":
+		if task.DeletedAt == nil {
+			return nil
+		}
+		return *task.DeletedAt
+	case "deleted_by":
+		return task.DeletedBy
+	}
+	return task.CustomFields[strings.TrimPrefix(column, CustomFieldPrefix)]
+}
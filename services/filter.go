@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/errors"
+	"taskify/models"
+	"taskify/query"
+)
+
+// MaxTaskFilterLength is the maximum length in bytes of a filter expression
+const MaxTaskFilterLength = 4096
+
+// taskFilterFields are the task fields that may be used in a filter expression.
+// Columns follow the relational layout of tasks, with labels in a task_labels table.
+var taskFilterFields = query.Schema{
+	"title":    {Path: "title", Column: "title", Type: query.TypeString},
+	"status":   {Path: "status", Column: "status", Type: query.TypeString},
+	"priority": {Path: "priority_rank", Column: "priority_rank", Type: query.TypeOrdered, Values: models.Priorities},
+	"assignee": {Path: "assignee", Column: "assignee", Type: query.TypeString},
+	"project":  {Path: "project", Column: "project", Type: query.TypeString},
+	"labels": {
+		Path:       "labels",
+		Column:     "task_labels.label",
+		ExistsFrom: "task_labels WHERE task_labels.task_id = tasks.id",
+		Type:       query.TypeString,
+	},
+	"due_at":     {Path: "due_at", Column: "due_at", Type: query.TypeDate},
+	"created_at": {Path: "created_at", Column: "created_at", Type: query.TypeDate},
+	"updated_at": {Path: "updated_at", Column: "updated_at", Type: query.TypeDate},
+}
+
+// customFieldFilterTypes maps custom field types to filter field types
+var customFieldFilterTypes = map[string]query.FieldType{
+	models.FieldTypeText:   query.TypeString,
+	models.FieldTypeNumber: query.TypeNumber,
+	models.FieldTypeDate:   query.TypeDate,
+	models.FieldTypeEnum:   query.TypeString,
+	models.FieldTypeUser:   query.TypeString,
+}
+
+// TaskFilterSchema returns the fields a task filter may reference, including custom
+// fields as cf.<key>. Custom fields are only available in MongoDB.
+func TaskFilterSchema(ctx context.Context) (query.Schema, error) {
+	fields, err := CustomFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(query.Schema, len(taskFilterFields)+len(fields))
+	for name, field := range taskFilterFields {
+		schema[name] = field
+	}
+	for key, field := range fields {
+		schema[CustomFieldPrefix+key] = query.Field{Path: "custom_fields." + key, Type: customFieldFilterTypes[field.Type]}
+	}
+	return schema, nil
+}
+
+// ParseTaskFilter parses and validates a filter expression and translates it into a
+// MongoDB task filter
+func ParseTaskFilter(ctx context.Context, expr string) (bson.M, error) {
+	if len(expr) > MaxTaskFilterLength {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("Filter must be at most %d bytes long", MaxTaskFilterLength))
+	}
+	node, err := query.Parse(expr)
+	if err != nil {
+		return nil, errors.NewInvalidInput(err.Error())
+	}
+
+	schema, err := TaskFilterSchema(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	if err := schema.Validate(node, time.Now()); err != nil {
+		return nil, errors.NewInvalidInput(err.Error())
+	}
+	return schema.ToBSON(node), nil
+}
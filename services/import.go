@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/events"
+	"taskify/importers"
+	"taskify/models"
+	"taskify/utils"
+)
+
+// importBatchAttempts is how many times a batch is written before giving up when
+// concurrent imports keep taking its external IDs
+const importBatchAttempts = 3
+
+// Lengths imported text is cut to, matching the limits on tasks
+const (
+	maxImportTitle       = 100
+	maxImportDescription = 500
+	maxImportLabel       = 50
+)
+
+// NewImportReport creates the report of an import of the given number of rows
+func NewImportReport(dryRun bool, rows int) *models.ImportReport {
+	return &models.ImportReport{
+		DryRun:     dryRun,
+		Total:      rows,
+		CreatedIDs: []string{},
+		Rows:       make([]models.ImportRowResult, rows),
+	}
+}
+
+// ReportImportRow records the outcome of validating the i-th row of an import and
+// returns its task, or nil if the row is invalid
+func ReportImportRow(report *models.ImportReport, i, row int, task *models.Task, problems []string) *models.Task {
+	report.Rows[i] = models.ImportRowResult{Row: row, Status: models.ImportRowValid, Errors: problems}
+	if len(problems) > 0 {
+		report.Rows[i].Status = models.ImportRowInvalid
+		return nil
+	}
+	report.Rows[i].ExternalID = task.ExternalID
+	return task
+}
+
+// ApplyImport skips duplicates among the validated tasks of an import and, unless this
+// is a dry run, inserts the remaining ones in batches. Tasks that are not inserted are
+// set to nil.
+func ApplyImport(ctx context.Context, actor string, report *models.ImportReport, tasks []*models.Task) error {
+	if err := markImportDuplicates(ctx, report, tasks); err != nil {
+		return err
+	}
+
+	var batch []int
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		report.Valid++
+		if report.DryRun {
+			continue
+		}
+		batch = append(batch, i)
+		if len(batch) == models.ImportBatchSize {
+			if err := insertImportBatch(ctx, actor, report, tasks, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := insertImportBatch(ctx, actor, report, tasks, batch); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case models.ImportRowCreated:
+			report.Created++
+			report.CreatedIDs = append(report.CreatedIDs, row.ID)
+		case models.ImportRowDuplicate:
+			report.Duplicates++
+		case models.ImportRowInvalid:
+			report.Invalid++
+		}
+	}
+	return nil
+}
+
+// ImportRowTask validates a mapped row and builds its task. Problems found while mapping
+// the row are reported along with those of validation.
+func ImportRowTask(ctx context.Context, row *models.ImportRow, problems []string) (*models.Task, []string, error) {
+	if err := utils.ValidateStruct(row); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, problems, nil
+	}
+
+	input, err := row.TaskDTO()
+	if err != nil {
+		return nil, []string{err.Error()}, nil
+	}
+	task, err := NewTask(ctx, input)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && appErr.StatusCode == http.StatusBadRequest {
+			return nil, []string{appErr.Message}, nil
+		}
+		return nil, nil, err
+	}
+	task.ExternalID = row.ExternalID
+	return task, nil, nil
+}
+
+// markImportDuplicates marks the rows whose external ID already belongs to a task, or to
+// an earlier row, as duplicates and drops their tasks
+func markImportDuplicates(ctx context.Context, report *models.ImportReport, tasks []*models.Task) error {
+	seen := map[string]bool{}
+	var ids bson.A
+	for i, task := range tasks {
+		if task == nil || task.ExternalID == "" {
+			continue
+		}
+		if seen[task.ExternalID] {
+			report.Rows[i].Status = models.ImportRowDuplicate
+			report.Rows[i].Errors = []string{"Duplicate of an earlier row"}
+			tasks[i] = nil
+			continue
+		}
+		seen[task.ExternalID] = true
+		ids = append(ids, task.ExternalID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	taken, err := takenExternalIDs(ctx, ids)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		if id, ok := taken[task.ExternalID]; ok {
+			report.Rows[i].Status = models.ImportRowDuplicate
+			report.Rows[i].ID = id
+			report.Rows[i].Errors = []string{"A task with this external ID already exists"}
+			tasks[i] = nil
+		}
+	}
+	return nil
+}
+
+// takenExternalIDs returns the IDs of the tasks, keyed by external ID, that have one of
+// the given external IDs. Trashed tasks count too, so restoring them cannot create
+// duplicates.
+func takenExternalIDs(ctx context.Context, externalIDs bson.A) (map[string]string, error) {
+	if len(externalIDs) == 0 {
+		return map[string]string{}, nil
+	}
+	cursor, err := config.DB.Collection("tasks").Find(ctx,
+		bson.M{"external_id": bson.M{"$in": externalIDs}},
+		options.Find().SetProjection(bson.M{"external_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.Task
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]string, len(existing))
+	for _, task := range existing {
+		taken[task.ExternalID] = task.ID.Hex()
+	}
+	return taken, nil
+}
+
+// insertImportBatch inserts the tasks of the given rows in a transaction with the events
+// of their creation. Rows whose external ID was taken by a concurrent import in the
+// meantime are reported as duplicates and their tasks set to nil; if such an import
+// commits while the batch is written, the batch is retried without its tasks.
+func insertImportBatch(ctx context.Context, actor string, report *models.ImportReport, tasks []*models.Task, batch []int) error {
+	var ids bson.A
+	for _, row := range batch {
+		if tasks[row].ExternalID != "" {
+			ids = append(ids, tasks[row].ExternalID)
+		}
+	}
+
+	var taken map[string]string
+	var err error
+	for attempt := 1; attempt <= importBatchAttempts; attempt++ {
+		err = WithTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			if taken, err = takenExternalIDs(ctx, ids); err != nil {
+				return err
+			}
+
+			var docs []interface{}
+			for _, row := range batch {
+				if _, ok := taken[tasks[row].ExternalID]; !ok {
+					docs = append(docs, tasks[row])
+				}
+			}
+			if len(docs) == 0 {
+				return nil
+			}
+			if _, err := config.DB.Collection("tasks").InsertMany(ctx, docs); err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				if err := RecordTaskChanges(ctx, actor, nil, doc.(*models.Task)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+
+	for _, row := range batch {
+		if id, ok := taken[tasks[row].ExternalID]; ok {
+			report.Rows[row].Status = models.ImportRowDuplicate
+			report.Rows[row].ID = id
+			report.Rows[row].Errors = []string{"A task with this external ID already exists"}
+			tasks[row] = nil
+			continue
+		}
+		report.Rows[row].Status = models.ImportRowCreated
+		report.Rows[row].ID = tasks[row].ID.Hex()
+	}
+	return nil
+}
+
+// ImportTrackerItems imports items read from the export file of another tracker, with
+// their comments, on behalf of the actor. It backs both the import endpoint and the
+// import command.
+func ImportTrackerItems(ctx context.Context, actor string, items []importers.Item, mapping *importers.Mapping, dryRun bool) (*models.ImportReport, error) {
+	if len(items) > models.MaxImportRows {
+		return nil, errors.NewInvalidInput(fmt.Sprintf("Import exceeds the maximum of %d rows", models.MaxImportRows))
+	}
+	for _, username := range mapping.Users {
+		if err := EnsureUserExists(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+
+	report := NewImportReport(dryRun, len(items))
+	tasks := make([]*models.Task, len(items))
+	comments := make([][]importers.Comment, len(items))
+	for i, item := range items {
+		row := models.ImportRow{
+			ExternalID:  item.ExternalID,
+			Title:       truncateText(strings.TrimSpace(item.Title), maxImportTitle),
+			Description: item.Description,
+			Status:      mapping.Status(item.Status),
+			Assignee:    mapping.User(item.Assignee),
+			Project:     mapping.Project,
+		}
+		comments[i] = item.Comments
+		// Keep long descriptions whole as the first comment
+		if len([]rune(item.Description)) > maxImportDescription {
+			row.Description = truncateText(item.Description, maxImportDescription)
+			first := importers.Comment{Body: item.Description, CreatedAt: item.CreatedAt}
+			comments[i] = append([]importers.Comment{first}, item.Comments...)
+		}
+		for _, label := range item.Labels {
+			label = truncateText(strings.TrimSpace(label), maxImportLabel)
+			if label != "" && !slices.Contains(row.Labels, label) {
+				row.Labels = append(row.Labels, label)
+			}
+		}
+		if item.DueAt != nil {
+			row.DueAt = item.DueAt.Format(time.RFC3339)
+		}
+
+		task, problems, err := ImportRowTask(ctx, &row, nil)
+		if err != nil {
+			return nil, err
+		}
+		if task != nil && !item.CreatedAt.IsZero() {
+			task.CreatedAt = item.CreatedAt
+		}
+		tasks[i] = ReportImportRow(report, i, item.Row, task, problems)
+	}
+
+	if err := ApplyImport(ctx, actor, report, tasks); err != nil {
+		return nil, err
+	}
+	for i, task := range tasks {
+		if task == nil || report.Rows[i].Status != models.ImportRowCreated || len(comments[i]) == 0 {
+			continue
+		}
+		if err := insertImportComments(ctx, actor, mapping, task, comments[i]); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// insertImportComments adds the comments of an imported item to its task, keeping their
+// dates. Comments of unmapped authors are attributed to the actor and name the author.
+func insertImportComments(ctx context.Context, actor string, mapping *importers.Mapping, task *models.Task, comments []importers.Comment) error {
+	docs := make([]interface{}, len(comments))
+	created := make([]*models.Comment, len(comments))
+	bodies := make(bson.A, len(comments))
+	for i, source := range comments {
+		author, body := mapping.User(source.Author), source.Body
+		if author == "" {
+			author = actor
+			if source.Author != "" {
+				body = "Originally posted by " + source.Author + ":\n\n" + body
+			}
+		}
+		comment := models.NewComment(task.ID, author, body)
+		comment.ID = primitive.NewObjectID()
+		if !source.CreatedAt.IsZero() {
+			comment.CreatedAt = source.CreatedAt
+		}
+		docs[i], created[i], bodies[i] = comment, comment, comment.Body
+	}
+
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := config.DB.Collection("comments").InsertMany(ctx, docs); err != nil {
+			return err
+		}
+		// Make the comments searchable through the task's text index
+		_, err := config.DB.Collection("tasks").UpdateOne(ctx,
+			bson.M{"_id": task.ID},
+			bson.M{"$push": bson.M{"comment_text": bson.M{"$each": bodies}}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range created {
+			if err := RecordEvent(ctx, events.NewTaskCommented(task, comment)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// truncateText shortens text to at most max characters, ending it with an ellipsis if
+// it was cut
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
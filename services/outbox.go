@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/events"
+	"taskify/models"
+)
+
+const (
+	// outboxBatchSize is the number of messages the relay reads at a time
+	outboxBatchSize = 100
+	// outboxLease is how long an instance remains the relay without renewing its lease;
+	// a single relay keeps the events of each task in order. The lease is renewed once
+	// half of it is left, which must exceed the time a message takes to be delivered.
+	outboxLease = 30 * time.Second
+	// Delays before retrying a message that could not be delivered to every sink
+	outboxBaseDelay = time.Second
+	outboxMaxDelay  = 5 * time.Minute
+)
+
+// outboxSink is an event sink, named in the messages delivered to it
+type outboxSink struct {
+	name string
+	sink events.Sink
+}
+
+var (
+	outboxSinks []outboxSink
+
+	// outboxWake is signalled when messages are committed, so that the relay picks them
+	// up without waiting for its next tick
+	outboxWake = make(chan struct{}, 1)
+
+	// relayInstance identifies this process in the relay lease
+	relayInstance = primitive.NewObjectID().Hex()
+)
+
+// Outbox metrics, read from the database when scraped so that every instance reports them
+var (
+	outboxLagGauge = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "taskify_outbox_lag_seconds",
+		Help: "Age of the oldest domain event in the outbox that was not published yet, or 0.",
+	}, outboxLag)
+	outboxPendingGauge = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "taskify_outbox_pending",
+		Help: "Number of domain events in the outbox that were not published yet.",
+	}, outboxPending)
+)
+
+// ConfigureOutbox sets up the sinks the relay publishes domain events to: the event bus,
+// whose subscribers keep the task history, event stream, webhooks and WebSockets up to
+// date, and the external sinks named in OUTBOX_SINKS. An unknown sink is fatal.
+func ConfigureOutbox() {
+	outboxSinks = []outboxSink{{name: "bus", sink: Events}}
+	for _, name := range config.AppConfig.OutboxSinks {
+		switch name {
+		case "http":
+			if config.AppConfig.OutboxHTTPURL == "" {
+				log.Fatal("OUTBOX_HTTP_URL is required by the http outbox sink")
+			}
+			outboxSinks = append(outboxSinks, outboxSink{name: name, sink: events.NewHTTPSink(config.AppConfig.OutboxHTTPURL)})
+		default:
+			log.Fatalf("Unknown outbox sink %q in OUTBOX_SINKS", name)
+		}
+	}
+
+	names := make([]string, len(outboxSinks))
+	for i, s := range outboxSinks {
+		names[i] = s.name
+	}
+	log.Printf("Relaying domain events to %s", strings.Join(names, ", "))
+}
+
+// RecordEvent writes a domain event to the outbox. Called with the context of a
+// transaction, the event is only written if the transaction commits.
+func RecordEvent(ctx context.Context, event events.Event) error {
+	payload, err := events.Encode(event)
+	if err != nil {
+		return err
+	}
+	message := models.NewOutboxMessage(event.EventID(), event.EventName(), event.AggregateID(), payload)
+	if _, err := config.DB.Collection("outbox").InsertOne(ctx, message); err != nil {
+		return err
+	}
+
+	if mongo.SessionFromContext(ctx) == nil {
+		WakeOutboxRelay()
+	}
+	return nil
+}
+
+// WithTransaction runs fn in a transaction, so that the changes it writes and the events
+// it records are committed together, and wakes the relay once they are. fn may be run
+// more than once if the transaction is retried. Within a transaction already, fn joins
+// it.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	if err != nil {
+		return err
+	}
+	WakeOutboxRelay()
+	return nil
+}
+
+// WakeOutboxRelay makes the relay check for messages without waiting for its next tick
+func WakeOutboxRelay() {
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// relayLease is the relay lease as held by this instance
+type relayLease struct {
+	expiresAt time.Time
+}
+
+// renew renews the lease once less than half of it is left, and reports whether this
+// instance holds it
+func (l *relayLease) renew(ctx context.Context) (bool, error) {
+	if time.Until(l.expiresAt) > outboxLease/2 {
+		return true, nil
+	}
+	now := time.Now()
+	held, err := acquireLease(ctx, "outbox-relay", outboxLease)
+	l.expiresAt = time.Time{}
+	if held {
+		l.expiresAt = now.Add(outboxLease)
+	}
+	return held, err
+}
+
+// RunOutboxRelay publishes the messages of the outbox every interval, and as soon as new
+// ones are committed, until ctx is cancelled. Only the instance holding the relay lease
+// publishes.
+func RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lease := &relayLease{}
+	for {
+		leader, err := lease.renew(ctx)
+		if err != nil {
+			log.Printf("Error: failed to acquire the outbox relay lease: %v", err)
+		}
+		for leader && ctx.Err() == nil {
+			processed, err := relayOutbox(ctx, lease)
+			if err != nil {
+				log.Printf("Error: outbox relay failed: %v", err)
+			}
+			if err != nil || processed < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-outboxWake:
+		}
+	}
+}
+
+// relayOutbox delivers a batch of unpublished messages to the sinks, oldest first, and
+// returns the number of messages processed. A message that fails holds back the later
+// messages of its aggregate until it is retried and delivered. The lease is renewed
+// before each message, and the batch stops if it is lost, so that no other instance
+// relays the same aggregates meanwhile.
+func relayOutbox(ctx context.Context, lease *relayLease) (int, error) {
+	if len(outboxSinks) == 0 {
+		return 0, errors.New("no outbox sink is configured")
+	}
+	collection := config.DB.Collection("outbox")
+	now := time.Now()
+
+	waiting, err := collection.Distinct(ctx, "aggregate_id", bson.M{
+		"published_at":    nil,
+		"next_attempt_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := collection.Find(ctx,
+		bson.M{"published_at": nil, "aggregate_id": bson.M{"$nin": waiting}},
+		options.Find().
+			SetSort(bson.D{{Key: "published_at", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(outboxBatchSize),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var messages []models.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return 0, err
+	}
+
+	held := map[string]bool{}
+	for i := range messages {
+		message := &messages[i]
+		if held[message.AggregateID] {
+			continue
+		}
+		if leader, err := lease.renew(ctx); !leader {
+			return i, err
+		}
+
+		// Give up on the message rather than deliver it after the lease expired
+		messageCtx, cancel := context.WithDeadline(ctx, lease.expiresAt)
+		err := relayMessage(messageCtx, message)
+		cancel()
+		if err != nil {
+			held[message.AggregateID] = true
+			log.Printf("Error: failed to relay %s %s: %v", message.Event, message.ID, err)
+		}
+	}
+	return len(messages), nil
+}
+
+// relayMessage delivers a message to the sinks it was not delivered to yet and marks it
+// published, or schedules its next attempt
+func relayMessage(ctx context.Context, message *models.OutboxMessage) error {
+	collection := config.DB.Collection("outbox")
+
+	var relayErr error
+	event, err := events.Decode(message.Event, message.Payload)
+	if err != nil {
+		relayErr = err
+	} else {
+		var errs []error
+		for _, s := range outboxSinks {
+			if slices.Contains(message.DeliveredTo, s.name) {
+				continue
+			}
+			if err := s.sink.Publish(ctx, event); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+				continue
+			}
+			message.DeliveredTo = append(message.DeliveredTo, s.name)
+		}
+		relayErr = errors.Join(errs...)
+	}
+
+	now := time.Now()
+	if relayErr == nil {
+		_, err := collection.UpdateOne(ctx, bson.M{"_id": message.ID}, bson.M{
+			"$set":   bson.M{"delivered_to": message.DeliveredTo, "published_at": now},
+			"$unset": bson.M{"next_attempt_at": ""},
+		})
+		return err
+	}
+
+	message.Attempts++
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": message.ID}, bson.M{"$set": bson.M{
+		"delivered_to":    message.DeliveredTo,
+		"attempts":        message.Attempts,
+		"last_error":      relayErr.Error(),
+		"next_attempt_at": now.Add(retryDelay(message.Attempts, outboxBaseDelay, outboxMaxDelay)),
+	}})
+	return errors.Join(relayErr, err)
+}
+
+// acquireLease takes or renews the named lease for this instance and reports whether it
+// holds it
+func acquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := config.DB.Collection("leases").UpdateOne(ctx,
+		bson.M{"_id": name, "$or": bson.A{
+			bson.M{"holder": relayInstance},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		}},
+		bson.M{"$set": bson.M{"holder": relayInstance, "expires_at": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another instance holds the lease
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// outboxLag returns the age in seconds of the oldest unpublished message
+func outboxLag() float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var oldest models.OutboxMessage
+	err := config.DB.Collection("outbox").FindOne(ctx,
+		bson.M{"published_at": nil},
+		options.FindOne().SetSort(bson.D{{Key: "published_at", Value: 1}, {Key: "created_at", Value: 1}}),
+	).Decode(&oldest)
+	if err == mongo.ErrNoDocuments {
+		return 0
+	}
+	if err != nil {
+		return math.NaN()
+	}
+	return time.Since(oldest.CreatedAt).Seconds()
+}
+
+// outboxPending returns the number of unpublished messages
+func outboxPending() float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	count, err := config.DB.Collection("outbox").CountDocuments(ctx, bson.M{"published_at": nil})
+	if err != nil {
+		return math.NaN()
+	}
+	return float64(count)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"taskify/config"
+	"taskify/events"
+	"taskify/models"
+)
+
+// GenerateNextOccurrence creates the task for the occurrence following the given one.
+// Generation is claimed atomically on the source task, so each occurrence produces its
+// successor at most once even when completion and the scheduler race. It returns nil
+// if the successor already exists or the series has ended. Occurrences that are already
+// past are skipped: the successor of an overdue occurrence is the first one after now, so
+// a long-overdue series catches up with a single occurrence rather than one per tick.
+func GenerateNextOccurrence(ctx context.Context, task *models.Task, actor string) (*models.Task, error) {
+	if task.Recurrence == nil || task.Recurrence.Ended || task.Recurrence.NextTaskID != nil || task.DueAt == nil {
+		return nil, nil
+	}
+
+	collection := config.DB.Collection("tasks")
+
+	after := *task.DueAt
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+	dueAt, ok, err := task.Recurrence.Next(after)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// The rule is exhausted (COUNT or UNTIL reached), so close the series
+		if err := StopSeries(ctx, task.Recurrence.SeriesID); err != nil {
+			return nil, err
+		}
+		task.Recurrence.Ended = true
+		task.Version++
+		return nil, nil
+	}
+
+	workflow, err := WorkflowFor(ctx, task.Project)
+	if err != nil {
+		return nil, err
+	}
+	next := task.NextOccurrence(dueAt, workflow.InitialStatus)
+	next.ID = primitive.NewObjectID()
+
+	claimed := false
+	err = WithTransaction(ctx, func(ctx context.Context) error {
+		claim, err := collection.UpdateOne(ctx,
+			bson.M{
+				"_id":                     task.ID,
+				"recurrence.next_task_id": bson.M{"$exists": false},
+				"recurrence.ended":        false,
+			},
+			bson.M{"$set": bson.M{"recurrence.next_task_id": next.ID}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+		if claimed = claim.ModifiedCount > 0; !claimed {
+			return nil
+		}
+
+		// A failure aborts the transaction, releasing the claim so the occurrence can be
+		// generated again later
+		if _, err := collection.InsertOne(ctx, next); err != nil {
+			return err
+		}
+		return RecordEvent(ctx, events.NewTaskCreated(actor, next))
+	})
+	if err != nil || !claimed {
+		return nil, err
+	}
+
+	task.Recurrence.NextTaskID = &next.ID
+	task.Version++
+	return next, nil
+}
+
+// GenerateDueOccurrences generates the successor of every recurring task whose due date has passed
+func GenerateDueOccurrences(ctx context.Context) (int, error) {
+	cursor, err := config.DB.Collection("tasks").Find(ctx, bson.M{
+		"recurrence":              bson.M{"$exists": true},
+		"recurrence.ended":        false,
+		"recurrence.next_task_id": bson.M{"$exists": false},
+		"due_at":                  bson.M{"$lte": time.Now()},
+		"deleted_at":              nil,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for i := range tasks {
+		next, err := GenerateNextOccurrence(ctx, &tasks[i], SystemActor)
+		if err != nil {
+			return generated, err
+		}
+		if next != nil {
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+// RunRecurrenceScheduler generates due occurrences every interval until ctx is cancelled
+func RunRecurrenceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		generated, err := GenerateDueOccurrences(ctx)
+		if err != nil {
+			log.Printf("Error: recurrence scheduler failed: %v", err)
+		} else if generated > 0 {
+			log.Printf("Generated %d recurring task occurrence(s)", generated)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StopSeries ends a recurring series so that no further occurrences are generated
+func StopSeries(ctx context.Context, seriesID primitive.ObjectID) error {
+	_, err := config.DB.Collection("tasks").UpdateMany(ctx,
+		bson.M{"recurrence.series_id": seriesID},
+		bson.M{"$set": bson.M{"recurrence.ended": true}, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
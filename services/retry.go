@@ -0,0 +1,17 @@
+package services
+
+import (
+	"math/rand"
+	"time"
+)
+
+// retryDelay returns the delay before the retry following the given number of failed
+// attempts: exponential backoff from base up to limit, with up to 10% jitter so that
+// retries after an outage are spread out
+func retryDelay(attempts int, base, limit time.Duration) time.Duration {
+	delay := limit
+	if shift := attempts - 1; shift < 20 {
+		delay = min(base<<shift, limit)
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay/10)+1))
+}
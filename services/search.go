@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+)
+
+// BackfillCommentText copies the bodies of existing comments onto tasks that predate
+// comment search, so their comments are covered by the text index
+func BackfillCommentText(ctx context.Context) (int, error) {
+	cursor, err := config.DB.Collection("comments").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"created_at": 1}}},
+		{{Key: "$group", Value: bson.M{"_id": "$task_id", "bodies": bson.M{"$push": "$body"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	tasks := config.DB.Collection("tasks")
+	for cursor.Next(ctx) {
+		var group struct {
+			TaskID primitive.ObjectID `bson:"_id"`
+			Bodies []string           `bson:"bodies"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return updated, err
+		}
+
+		result, err := tasks.UpdateOne(ctx,
+			bson.M{"_id": group.TaskID, "comment_text": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"comment_text": group.Bodies}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return updated, err
+		}
+		updated += int(result.ModifiedCount)
+	}
+	return updated, cursor.Err()
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"taskify/errors"
+)
+
+// taskSortFields maps the task fields that may be sorted on to their document paths
+var taskSortFields = map[string]string{
+	"title":      "title",
+	"status":     "status",
+	"priority":   "priority_rank",
+	"assignee":   "assignee",
+	"project":    "project",
+	"due_at":     "due_at",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// TaskSort translates a comma-separated list of sort fields such as -priority,due_at,title
+// into a MongoDB sort. A leading - sorts a field in descending order. The task ID is always
+// appended as the last key so results are stable across pages.
+func TaskSort(ctx context.Context, param string) (bson.D, error) {
+	var sortKeys bson.D
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		order := 1
+		if strings.HasPrefix(name, "-") {
+			order = -1
+			name = name[1:]
+		}
+
+		path, ok := taskSortFields[name]
+		if !ok && strings.HasPrefix(name, CustomFieldPrefix) {
+			var err error
+			if path, err = CustomFieldSortKey(ctx, name); err != nil {
+				return nil, err
+			}
+			ok = true
+		}
+		if !ok {
+			return nil, errors.NewInvalidInput("Cannot sort by '" + name + "'. Allowed fields: " + strings.Join(sortableTaskFields(), ", "))
+		}
+		if seen[path] {
+			return nil, errors.NewInvalidInput("Duplicate sort field '" + name + "'")
+		}
+		seen[path] = true
+		sortKeys = append(sortKeys, bson.E{Key: path, Value: order})
+	}
+
+	return append(sortKeys, bson.E{Key: "_id", Value: 1}), nil
+}
+
+// sortableTaskFields lists the names accepted by TaskSort in alphabetical order
+func sortableTaskFields() []string {
+	return append(sortedKeys(taskSortFields), CustomFieldPrefix+"<key>")
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"taskify/models"
+)
+
+const (
+	// streamBufferSize is the number of recent events kept for clients resuming a stream
+	streamBufferSize = 1000
+	// streamSubscriberBuffer is the number of events a subscriber may fall behind by before
+	// it is dropped; dropped clients reconnect and resume from the buffer
+	streamSubscriberBuffer = 64
+)
+
+// StreamEvent is a task event as sent to event stream subscribers. IDs increase
+// monotonically and start from the time the stream was created, so IDs issued before a
+// restart are older than any event in the buffer.
+type StreamEvent struct {
+	ID      uint64
+	EventID string // ID of the task event, the same across redeliveries
+	Event   string
+	Project string
+	Data    []byte
+}
+
+// EventStream fans task events out to subscribers in this process and keeps the most
+// recent ones so that subscribers can resume after reconnecting
+type EventStream struct {
+	mu          sync.Mutex
+	buffer      []StreamEvent
+	start       int                 // Index of the oldest event in buffer once it is full
+	buffered    map[string]struct{} // Task event IDs of the events in buffer
+	nextID      uint64
+	subscribers map[chan StreamEvent]struct{}
+	closed      bool
+}
+
+// TaskStream is the stream of task events
+var TaskStream = NewEventStream()
+
+// NewEventStream creates an empty event stream
+func NewEventStream() *EventStream {
+	return &EventStream{
+		buffer:      make([]StreamEvent, 0, streamBufferSize),
+		buffered:    make(map[string]struct{}, streamBufferSize),
+		nextID:      uint64(time.Now().UnixMilli()) * 1000,
+		subscribers: map[chan StreamEvent]struct{}{},
+	}
+}
+
+// Publish assigns the event an ID and sends it to all subscribers. Events redelivered
+// while still in the buffer are ignored. Subscribers that have fallen too far behind are
+// dropped.
+func (s *EventStream) Publish(event *models.TaskEvent, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if _, ok := s.buffered[event.ID]; ok {
+		return
+	}
+
+	streamEvent := StreamEvent{ID: s.nextID, EventID: event.ID, Event: event.Event, Data: data}
+	if event.Task != nil {
+		streamEvent.Project = event.Task.Project
+	}
+	s.nextID++
+	if len(s.buffer) < streamBufferSize {
+		s.buffer = append(s.buffer, streamEvent)
+	} else {
+		delete(s.buffered, s.buffer[s.start].EventID)
+		s.buffer[s.start] = streamEvent
+		s.start = (s.start + 1) % streamBufferSize
+	}
+	s.buffered[event.ID] = struct{}{}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- streamEvent:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events published from now on, closed when the
+// subscriber is dropped or the stream is closed, and a function to unsubscribe. With a
+// last event ID, the events after it that are still buffered are returned as backlog;
+// resumed is false if events after it have already left the buffer.
+func (s *EventStream) Subscribe(lastID *uint64) (backlog []StreamEvent, resumed bool, events <-chan StreamEvent, unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan StreamEvent, streamSubscriberBuffer)
+	if s.closed {
+		close(ch)
+		return nil, lastID == nil, ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+	unsubscribe = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	if lastID == nil {
+		return nil, true, ch, unsubscribe
+	}
+	oldest := s.nextID - uint64(len(s.buffer))
+	if *lastID+1 < oldest || *lastID >= s.nextID {
+		return nil, false, ch, unsubscribe
+	}
+	for i := range s.buffer {
+		event := s.buffer[(s.start+i)%len(s.buffer)]
+		if event.ID > *lastID {
+			backlog = append(backlog, event)
+		}
+	}
+	return backlog, true, ch, unsubscribe
+}
+
+// Close closes the channels of all subscribers, ending their streams, and stops
+// accepting new events. It is called when the server shuts down.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
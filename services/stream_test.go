@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"taskify/models"
+)
+
+// publishEvents publishes n events with distinct event IDs and returns the stream ID of
+// the first one
+func publishEvents(s *EventStream, n int) uint64 {
+	first := s.nextID
+	for i := 0; i < n; i++ {
+		id := first + uint64(i)
+		s.Publish(&models.TaskEvent{ID: fmt.Sprint("event-", id), Event: "task.updated"}, nil)
+	}
+	return first
+}
+
+func backlogIDs(backlog []StreamEvent) []uint64 {
+	ids := make([]uint64, len(backlog))
+	for i, event := range backlog {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestEventStreamResume(t *testing.T) {
+	s := NewEventStream()
+	first := publishEvents(s, 3)
+
+	tests := []struct {
+		name    string
+		lastID  *uint64
+		resumed bool
+		backlog int
+	}{
+		{"no last event", nil, true, 0},
+		{"before the oldest", idPtr(first - 2), false, 0},
+		{"just before the oldest", idPtr(first - 1), true, 3},
+		{"the oldest", idPtr(first), true, 2},
+		{"the newest", idPtr(first + 2), true, 0},
+		{"not issued yet", idPtr(first + 3), false, 0},
+	}
+	for _, tt := range tests {
+		backlog, resumed, _, unsubscribe := s.Subscribe(tt.lastID)
+		unsubscribe()
+		if resumed != tt.resumed || len(backlog) != tt.backlog {
+			t.Errorf("%s: Subscribe() = %d events, resumed %v; want %d events, resumed %v",
+				tt.name, len(backlog), resumed, tt.backlog, tt.resumed)
+		}
+		for i, event := range backlog {
+			if want := first + uint64(3-tt.backlog+i); event.ID != want {
+				t.Errorf("%s: backlog IDs = %v, want them to end at %d", tt.name, backlogIDs(backlog), first+2)
+				break
+			}
+		}
+	}
+}
+
+func TestEventStreamWraparound(t *testing.T) {
+	s := NewEventStream()
+	const overflow = 5
+	first := publishEvents(s, streamBufferSize+overflow)
+	oldest := first + overflow
+
+	backlog, resumed, _, unsubscribe := s.Subscribe(idPtr(oldest - 1))
+	unsubscribe()
+	if !resumed || len(backlog) != streamBufferSize {
+		t.Fatalf("Subscribe(oldest-1) = %d events, resumed %v; want %d events, resumed", len(backlog), resumed, streamBufferSize)
+	}
+	for i, event := range backlog {
+		if event.ID != oldest+uint64(i) {
+			t.Fatalf("backlog[%d].ID = %d, want %d", i, event.ID, oldest+uint64(i))
+		}
+	}
+
+	backlog, resumed, _, unsubscribe = s.Subscribe(idPtr(oldest + streamBufferSize - 3))
+	unsubscribe()
+	if !resumed || len(backlog) != 2 || backlog[0].ID != oldest+streamBufferSize-2 {
+		t.Errorf("Subscribe(newest-2) backlog = %v, resumed %v; want the 2 newest", backlogIDs(backlog), resumed)
+	}
+
+	if _, resumed, _, unsubscribe := s.Subscribe(idPtr(oldest - 2)); resumed {
+		t.Errorf("Subscribe(oldest-2) resumed, want events after it reported as lost")
+		unsubscribe()
+	}
+}
+
+func TestEventStreamDedup(t *testing.T) {
+	s := NewEventStream()
+	_, _, events, unsubscribe := s.Subscribe(nil)
+	defer unsubscribe()
+
+	event := &models.TaskEvent{ID: "event-1", Event: "task.created"}
+	s.Publish(event, nil)
+	s.Publish(event, nil)
+	if len(events) != 1 {
+		t.Fatalf("a redelivered event was sent %d times, want once", len(events))
+	}
+	if got := (<-events).EventID; got != event.ID {
+		t.Errorf("EventID = %q, want %q", got, event.ID)
+	}
+
+	// Once the event has left the buffer, it can no longer be recognized
+	s2 := NewEventStream()
+	s2.Publish(event, nil)
+	publishEvents(s2, streamBufferSize)
+	if _, ok := s2.buffered[event.ID]; ok {
+		t.Errorf("event %q is still tracked after leaving the buffer", event.ID)
+	}
+	if len(s2.buffered) != streamBufferSize {
+		t.Errorf("%d event IDs tracked, want %d", len(s2.buffered), streamBufferSize)
+	}
+	next := s2.nextID
+	s2.Publish(event, nil)
+	if s2.nextID != next+1 {
+		t.Errorf("an event that left the buffer was not published again")
+	}
+}
+
+func TestEventStreamClose(t *testing.T) {
+	s := NewEventStream()
+	_, _, events, _ := s.Subscribe(nil)
+	s.Close()
+	if _, ok := <-events; ok {
+		t.Errorf("subscriber channel is open after Close")
+	}
+
+	s.Publish(&models.TaskEvent{ID: "event-1"}, nil)
+	backlog, _, events, _ := s.Subscribe(idPtr(s.nextID - 1))
+	if _, ok := <-events; ok || len(backlog) != 0 {
+		t.Errorf("Subscribe() after Close returned an open stream")
+	}
+}
+
+func idPtr(id uint64) *uint64 {
+	return &id
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/events"
+	"taskify/models"
+)
+
+// NewTask validates the input against the task's workflow and custom fields and builds
+// the new task, with its ID allocated, without inserting it
+func NewTask(ctx context.Context, input models.CreateTaskDTO) (*models.Task, error) {
+	if input.Assignee != "" {
+		if err := EnsureUserExists(ctx, input.Assignee); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.ParentID != nil {
+		if err := EnsureTaskExists(ctx, *input.ParentID); err != nil {
+			var appErr *errors.AppError
+			if stderrors.As(err, &appErr) && appErr.StatusCode == http.StatusNotFound {
+				err = errors.NewInvalidInput("Parent task does not exist")
+			}
+			return nil, err
+		}
+	}
+
+	workflow, err := WorkflowFor(ctx, input.Project)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err)
+	}
+	status := workflow.InitialStatus
+	if input.Status != "" {
+		if err := workflow.CheckStatus(input.Status); err != nil {
+			return nil, errors.NewInvalidInput(err.Error())
+		}
+		status = input.Status
+	}
+
+	task := models.NewTask(input.Title, status)
+	if input.Description != "" {
+		task.Description = input.Description
+	}
+	if input.Priority != "" {
+		task.SetPriority(input.Priority)
+	}
+	task.Project = input.Project
+	task.ParentID = input.ParentID
+	task.Assignee = input.Assignee
+	task.DueAt = input.DueAt
+	task.Labels = input.Labels
+
+	if err := ApplyCustomFields(ctx, task, input.CustomFields, true); err != nil {
+		return nil, err
+	}
+
+	// Allocate the ID up front so a recurring task can anchor its series on itself
+	task.ID = primitive.NewObjectID()
+	if input.Recurrence != nil {
+		if err := task.SetRecurrence(*input.Recurrence); err != nil {
+			return nil, errors.NewInvalidInput(err.Error())
+		}
+	}
+	return task, nil
+}
+
+// EnsureTaskExists returns a not found error if the task does not exist or is in the trash
+func EnsureTaskExists(ctx context.Context, taskID primitive.ObjectID) error {
+	collection := config.DB.Collection("tasks")
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": taskID, "deleted_at": nil}, options.Count().SetLimit(1))
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if count == 0 {
+		return errors.NewNotFound("Task")
+	}
+	return nil
+}
+
+// RecordTaskChanges records the event of a change from before to after in the outbox; a
+// nil before means the task was created
+func RecordTaskChanges(ctx context.Context, actor string, before, after *models.Task) error {
+	if before == nil {
+		return RecordEvent(ctx, events.NewTaskCreated(actor, after))
+	}
+	if event := events.NewTaskUpdated(actor, before, after); event != nil {
+		return RecordEvent(ctx, event)
+	}
+	return nil
+}
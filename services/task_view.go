@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/errors"
+	"taskify/models"
+)
+
+// taskFieldPaths maps the task fields that may be requested with fields= to their
+// document paths
+var taskFieldPaths = map[string]string{
+	"id":            "_id",
+	"title":         "title",
+	"description":   "description",
+	"status":        "status",
+	"priority":      "priority",
+	"project":       "project",
+	"assignee":      "assignee",
+	"parent_id":     "parent_id",
+	"external_id":   "external_id",
+	"due_at":        "due_at",
+	"labels":        "labels",
+	"recurrence":    "recurrence",
+	"custom_fields": "custom_fields",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+	"deleted_at":    "deleted_at",
+	"deleted_by":    "deleted_by",
+}
+
+// Relations that may be embedded in tasks with include=
+const (
+	// IncludeAssignee embeds the assigned user as assignee_user
+	IncludeAssignee = "assignee"
+	// IncludeCommentCount embeds the number of comments as comment_count
+	IncludeCommentCount = "comment_count"
+	// IncludeSubtasks embeds the tasks whose parent is the task as subtasks
+	IncludeSubtasks = "subtasks"
+)
+
+var taskIncludes = []string{IncludeAssignee, IncludeCommentCount, IncludeSubtasks}
+
+// searchResultFields are always kept in sparse full-text search results
+var searchResultFields = []string{"score", "highlights"}
+
+// TaskView is the shape of the tasks requested by a client: a sparse set of fields
+// and the related data to embed in each task
+type TaskView struct {
+	// Fields lists the JSON names of the requested fields; nil means all fields
+	Fields  []string
+	Include []string
+}
+
+// ParseTaskView parses the comma-separated fields and include query parameters.
+// The task ID is always returned.
+func ParseTaskView(fields, include string) (*TaskView, error) {
+	view := &TaskView{}
+
+	if fields != "" {
+		view.Fields = []string{"id"}
+		for _, name := range splitList(fields) {
+			if _, ok := taskFieldPaths[name]; !ok {
+				return nil, errors.NewInvalidInput("Unknown field '" + name + "'. Allowed fields: " + strings.Join(sortedKeys(taskFieldPaths), ", "))
+			}
+			if name != "id" {
+				view.Fields = append(view.Fields, name)
+			}
+		}
+	}
+
+	for _, name := range splitList(include) {
+		if !contains(taskIncludes, name) {
+			return nil, errors.NewInvalidInput("Unknown include '" + name + "'. Allowed: " + strings.Join(taskIncludes, ", "))
+		}
+		if !contains(view.Include, name) {
+			view.Include = append(view.Include, name)
+		}
+	}
+	return view, nil
+}
+
+// IsFull reports whether the view is a whole task without embedded relations
+func (v *TaskView) IsFull() bool {
+	return v.Fields == nil && len(v.Include) == 0
+}
+
+// Projection returns the MongoDB projection loading the requested fields together with
+// the given extra document paths, or nil if all fields are requested
+func (v *TaskView) Projection(extra ...string) bson.M {
+	if v.Fields == nil {
+		return nil
+	}
+
+	projection := bson.M{}
+	for _, name := range v.Fields {
+		projection[taskFieldPaths[name]] = 1
+	}
+	if contains(v.Include, IncludeAssignee) {
+		projection["assignee"] = 1
+	}
+	for _, path := range extra {
+		projection[path] = 1
+	}
+
+	pruneProjection(projection)
+	return projection
+}
+
+// pruneProjection removes paths whose parent is also projected, as MongoDB rejects a
+// projection containing both
+func pruneProjection(projection bson.M) {
+	for path := range projection {
+		for parent := range projection {
+			if strings.HasPrefix(path, parent+".") {
+				delete(projection, path)
+				break
+			}
+		}
+	}
+}
+
+// Render converts the items to their JSON form restricted to the requested fields and
+// embeds the requested relations. tasks holds the task of each item.
+func (v *TaskView) Render(ctx context.Context, items []interface{}, tasks []*models.Task) ([]map[string]interface{}, error) {
+	views := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &views[i]); err != nil {
+			return nil, err
+		}
+
+		if v.Fields != nil {
+			keep := append(append([]string{}, v.Fields...), searchResultFields...)
+			for key := range views[i] {
+				if !contains(keep, key) {
+					delete(views[i], key)
+				}
+			}
+		}
+	}
+
+	for _, include := range v.Include {
+		var err error
+		switch include {
+		case IncludeAssignee:
+			err = embedAssignees(ctx, views, tasks)
+		case IncludeCommentCount:
+			err = embedCommentCounts(ctx, views, tasks)
+		case IncludeSubtasks:
+			err = embedSubtasks(ctx, views, tasks)
+		}
+		if err != nil {
+			return nil, errors.NewDatabaseError(err)
+		}
+	}
+	return views, nil
+}
+
+func embedAssignees(ctx context.Context, views []map[string]interface{}, tasks []*models.Task) error {
+	var usernames []string
+	for _, task := range tasks {
+		if task.Assignee != "" {
+			usernames = append(usernames, task.Assignee)
+		}
+	}
+
+	users := map[string]*models.User{}
+	if len(usernames) > 0 {
+		cursor, err := config.DB.Collection("users").Find(ctx, bson.M{"username": bson.M{"$in": usernames}})
+		if err != nil {
+			return err
+		}
+		var found []*models.User
+		if err := cursor.All(ctx, &found); err != nil {
+			return err
+		}
+		for _, user := range found {
+			users[user.Username] = user
+		}
+	}
+
+	for i, task := range tasks {
+		views[i]["assignee_user"] = users[task.Assignee]
+	}
+	return nil
+}
+
+func embedCommentCounts(ctx context.Context, views []map[string]interface{}, tasks []*models.Task) error {
+	cursor, err := config.DB.Collection("comments").Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"task_id": bson.M{"$in": taskIDs(tasks)}}},
+		bson.M{"$group": bson.M{"_id": "$task_id", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	var counts []struct {
+		TaskID primitive.ObjectID `bson:"_id"`
+		Count  int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return err
+	}
+
+	byTask := map[primitive.ObjectID]int{}
+	for _, count := range counts {
+		byTask[count.TaskID] = count.Count
+	}
+	for i, task := range tasks {
+		views[i]["comment_count"] = byTask[task.ID]
+	}
+	return nil
+}
+
+func embedSubtasks(ctx context.Context, views []map[string]interface{}, tasks []*models.Task) error {
+	filter := bson.M{"parent_id": bson.M{"$in": taskIDs(tasks)}, "deleted_at": nil}
+	cursor, err := config.DB.Collection("tasks").Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	var subtasks []models.Task
+	if err := cursor.All(ctx, &subtasks); err != nil {
+		return err
+	}
+
+	byParent := map[primitive.ObjectID][]models.Task{}
+	for _, subtask := range subtasks {
+		byParent[*subtask.ParentID] = append(byParent[*subtask.ParentID], subtask)
+	}
+	for i, task := range tasks {
+		children := byParent[task.ID]
+		if children == nil {
+			children = []models.Task{}
+		}
+		views[i]["subtasks"] = children
+	}
+	return nil
+}
+
+func taskIDs(tasks []*models.Task) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+// splitList splits a comma-separated query parameter, ignoring empty entries
+func splitList(param string) []string {
+	var items []string
+	for _, item := range strings.Split(param, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
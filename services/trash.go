@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+	"taskify/events"
+	"taskify/models"
+)
+
+// SystemActor is the actor recorded for changes made by background jobs
+const SystemActor = "system"
+
+// PurgeTask permanently removes a trashed task together with its comments and attachments.
+// It returns mongo.ErrNoDocuments if the task does not exist or is not in the trash.
+func PurgeTask(ctx context.Context, taskID primitive.ObjectID, actor string) error {
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.DB.Collection("tasks").DeleteOne(ctx, bson.M{
+			"_id":        taskID,
+			"deleted_at": bson.M{"$ne": nil},
+		})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		if _, err := config.DB.Collection("comments").DeleteMany(ctx, bson.M{"task_id": taskID}); err != nil {
+			return err
+		}
+		return RecordEvent(ctx, events.NewTaskPurged(actor, taskID))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Stored content cannot be removed in the transaction, so attachments go once it commits
+	return purgeAttachments(ctx, taskID)
+}
+
+// purgeAttachments removes the stored content and metadata of all attachments of a task
+func purgeAttachments(ctx context.Context, taskID primitive.ObjectID) error {
+	collection := config.DB.Collection("attachments")
+	cursor, err := collection.Find(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		return err
+	}
+
+	var attachments []models.Attachment
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := config.Storage.Delete(ctx, attachment.StorageKey); err != nil {
+			return err
+		}
+	}
+
+	_, err = collection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
+
+// PurgeExpiredTrash purges every task that has been in the trash for longer than retention
+func PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	cursor, err := config.DB.Collection("tasks").Find(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, task := range tasks {
+		err := PurgeTask(ctx, task.ID, SystemActor)
+		if err == mongo.ErrNoDocuments {
+			// Restored or purged by someone else in the meantime
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunTrashRetention purges expired trash every interval until ctx is cancelled
+func RunTrashRetention(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := PurgeExpiredTrash(ctx, retention)
+		if err != nil {
+			log.Printf("Error: trash retention failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d task(s) from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
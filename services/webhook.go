@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"taskify/config"
+	"taskify/models"
+)
+
+// Webhook delivery headers
+const (
+	// WebhookSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the body,
+	// keyed with the webhook's secret
+	WebhookSignatureHeader = "X-Taskify-Signature-256"
+	WebhookEventHeader     = "X-Taskify-Event"
+	WebhookDeliveryHeader  = "X-Taskify-Delivery"
+)
+
+const (
+	// webhookTimeout bounds how long a receiver may take to respond
+	webhookTimeout = 10 * time.Second
+	// webhookLease is how long a delivery being attempted is hidden from other dispatchers
+	webhookLease = time.Minute
+	// webhookBaseDelay is the delay before the first retry; it doubles with every attempt
+	webhookBaseDelay = 30 * time.Second
+	webhookMaxDelay  = time.Hour
+	// webhookResponseLimit is how much of response bodies is kept in the delivery log
+	webhookResponseLimit = 512
+)
+
+// webhookClient only connects to public addresses and does not follow redirects, so that
+// webhooks cannot be used to reach services on the server's network and read their
+// responses from the delivery log
+var webhookClient = newWebhookClient()
+
+// nonPublicPrefixes are special-purpose ranges not covered by the netip.Addr predicates
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the receiver, bypassing the address check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublicOnly refuses connections to loopback, private, link-local and other
+// non-public addresses. It runs after the host name was resolved, for every address
+// dialed.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(ip.Unmap()) {
+		return fmt.Errorf("webhook address %s is not public", ip)
+	}
+	return nil
+}
+
+// isPublicAddr reports whether ip is a globally routable unicast address
+func isPublicAddr(ip netip.Addr) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookWake wakes the dispatcher when deliveries are enqueued
+var webhookWake = make(chan struct{}, 1)
+
+// EnqueueWebhooks creates a delivery of the event, encoded as body, to every active
+// webhook subscribed to it. Webhooks the event was already queued for are skipped, as
+// events may be relayed more than once.
+func EnqueueWebhooks(ctx context.Context, event *models.TaskEvent, body []byte) error {
+	cursor, err := config.DB.Collection("webhooks").Find(ctx, bson.M{"active": true, "events": event.Event})
+	if err != nil {
+		return err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	queued, err := config.DB.Collection("webhook_deliveries").Distinct(ctx, "webhook_id", bson.M{"event_id": event.ID})
+	if err != nil {
+		return err
+	}
+	skip := make(map[primitive.ObjectID]bool, len(queued))
+	for _, id := range queued {
+		if id, ok := id.(primitive.ObjectID); ok {
+			skip[id] = true
+		}
+	}
+
+	var deliveries []interface{}
+	for _, webhook := range webhooks {
+		if !skip[webhook.ID] {
+			deliveries = append(deliveries, models.NewWebhookDelivery(webhook.ID, event.ID, event.Event, body))
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if _, err := config.DB.Collection("webhook_deliveries").InsertMany(ctx, deliveries); err != nil {
+		return err
+	}
+	wakeWebhookDispatcher()
+	return nil
+}
+
+// Redeliver queues a new delivery of the same event as an earlier one, with the same
+// payload and event ID
+func Redeliver(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	redelivery := models.NewWebhookDelivery(delivery.WebhookID, delivery.EventID, delivery.Event, delivery.Payload)
+	redelivery.RedeliveryOf = &delivery.ID
+
+	result, err := config.DB.Collection("webhook_deliveries").InsertOne(ctx, redelivery)
+	if err != nil {
+		return nil, err
+	}
+	redelivery.ID = result.InsertedID.(primitive.ObjectID)
+	wakeWebhookDispatcher()
+	return redelivery, nil
+}
+
+// SignWebhookPayload returns the signature header value of a payload
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunWebhookDispatcher attempts due webhook deliveries every interval, and as soon as new
+// ones are enqueued, until ctx is cancelled
+func RunWebhookDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := DeliverDueWebhooks(ctx); err != nil {
+			log.Printf("Error: webhook dispatcher failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-webhookWake:
+		}
+	}
+}
+
+// DeliverDueWebhooks attempts every pending delivery that is due and returns the number
+// of attempts made. Deliveries are claimed one at a time, so several dispatchers can run.
+func DeliverDueWebhooks(ctx context.Context) (int, error) {
+	collection := config.DB.Collection("webhook_deliveries")
+	attempted := 0
+	for ctx.Err() == nil {
+		now := time.Now()
+		var delivery models.WebhookDelivery
+		err := collection.FindOneAndUpdate(ctx,
+			bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_attempt_at": now.Add(webhookLease)}},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&delivery)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return attempted, err
+		}
+
+		if err := attemptDelivery(ctx, &delivery); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// attemptDelivery sends a delivery to its webhook and records the outcome. Deliveries to
+// webhooks that were deleted or disabled since they were enqueued fail without an attempt.
+func attemptDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	deliveries := config.DB.Collection("webhook_deliveries")
+	var webhook models.Webhook
+	err := config.DB.Collection("webhooks").FindOne(ctx, bson.M{"_id": delivery.WebhookID}).Decode(&webhook)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if err == mongo.ErrNoDocuments || !webhook.Active {
+		_, err := deliveries.UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{
+			"$set":   bson.M{"status": models.DeliveryFailed, "completed_at": time.Now()},
+			"$unset": bson.M{"next_attempt_at": ""},
+		})
+		return err
+	}
+
+	attempt := sendWebhook(ctx, &webhook, delivery)
+	attempts := len(delivery.Attempts) + 1
+	succeeded := attempt.Error == ""
+	failed := !succeeded && attempts >= config.AppConfig.WebhookMaxAttempts
+
+	now := time.Now()
+	update := bson.M{"$push": bson.M{"attempts": attempt}}
+	switch {
+	case succeeded:
+		update["$set"] = bson.M{"status": models.DeliverySucceeded, "completed_at": now}
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	case failed:
+		update["$set"] = bson.M{"status": models.DeliveryFailed, "completed_at": now}
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	default:
+		update["$set"] = bson.M{"next_attempt_at": now.Add(retryDelay(attempts, webhookBaseDelay, webhookMaxDelay))}
+	}
+	if _, err := deliveries.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update); err != nil {
+		return err
+	}
+
+	switch {
+	case succeeded && webhook.ConsecutiveFailures > 0:
+		_, err := config.DB.Collection("webhooks").UpdateOne(ctx,
+			bson.M{"_id": webhook.ID}, bson.M{"$set": bson.M{"consecutive_failures": 0}})
+		return err
+	case failed:
+		return recordWebhookFailure(ctx, &webhook)
+	}
+	return nil
+}
+
+// recordWebhookFailure counts a failed delivery against the webhook and disables it once
+// too many deliveries in a row have failed
+func recordWebhookFailure(ctx context.Context, webhook *models.Webhook) error {
+	webhooks := config.DB.Collection("webhooks")
+	if _, err := webhooks.UpdateOne(ctx, bson.M{"_id": webhook.ID}, bson.M{"$inc": bson.M{"consecutive_failures": 1}}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := webhooks.UpdateOne(ctx,
+		bson.M{
+			"_id":                  webhook.ID,
+			"active":               true,
+			"consecutive_failures": bson.M{"$gte": config.AppConfig.WebhookDisableAfterFailures},
+		},
+		bson.M{"$set": bson.M{"active": false, "disabled_at": now, "updated_at": now}})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Disabled webhook %s to %s after %d failed deliveries in a row",
+			webhook.ID.Hex(), webhook.URL, config.AppConfig.WebhookDisableAfterFailures)
+	}
+	return nil
+}
+
+// sendWebhook posts a delivery's payload to the webhook. Any response other than 2xx is
+// a failed attempt.
+func sendWebhook(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (attempt models.WebhookAttempt) {
+	attempt.At = time.Now()
+	defer func() {
+		attempt.Duration = time.Since(attempt.At).Milliseconds()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Taskify-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return attempt
+}
+
+// wakeWebhookDispatcher makes the dispatcher check for due deliveries without waiting
+// for its next tick
+func wakeWebhookDispatcher() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"taskify/config"
+	"taskify/models"
+)
+
+// WorkflowFor returns the workflow used by tasks of the given project, falling back to
+// the default workflow when the project does not define one
+func WorkflowFor(ctx context.Context, project string) (*models.Workflow, error) {
+	if project == "" {
+		return config.DefaultWorkflow, nil
+	}
+
+	var projectWorkflow models.ProjectWorkflow
+	err := config.DB.Collection("workflows").FindOne(ctx, bson.M{"_id": project}).Decode(&projectWorkflow)
+	if err == mongo.ErrNoDocuments {
+		return config.DefaultWorkflow, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &projectWorkflow.Workflow, nil
+}
+
+// UnknownStatuses returns the statuses used by tasks of the project that the workflow does not define
+func UnknownStatuses(ctx context.Context, project string, workflow *models.Workflow) ([]string, error) {
+	values, err := config.DB.Collection("tasks").Distinct(ctx, "status", bson.M{
+		"project": project,
+		"status":  bson.M{"$nin": workflow.StatusNames()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]string, 0, len(values))
+	for _, value := range values {
+		if status, ok := value.(string); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
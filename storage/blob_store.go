@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a blob does not exist in the store
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores and retrieves binary objects by key
+type BlobStore interface {
+	// Put writes the content of r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key for reading and seeking
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a full-text query into the terms that should be highlighted.
+// Quotes are ignored and negated terms (prefixed with -) are dropped.
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		if strings.HasPrefix(term, "-") {
+			continue
+		}
+		term = strings.Trim(term, `"'`)
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Highlight returns an excerpt of text around the first word starting with one of the
+// terms. Every matching word in the excerpt is wrapped in <mark> tags and the rest of the
+// excerpt is HTML-escaped. Prefix matching approximates the stemming of the search index,
+// so "report" also highlights "reports". It reports false if no word matches.
+func Highlight(text string, terms []string, radius int) (string, bool) {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	lowerTerms := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i, r := range termRunes {
+			termRunes[i] = unicode.ToLower(r)
+		}
+		lowerTerms = append(lowerTerms, termRunes)
+	}
+
+	// Find the words that start with a term
+	type span struct{ start, end int }
+	var matches []span
+	for i := 0; i < len(lower); i++ {
+		if i > 0 && isWordRune(lower[i-1]) {
+			continue
+		}
+		for _, term := range lowerTerms {
+			if len(term) == 0 || !hasRunePrefix(lower[i:], term) {
+				continue
+			}
+			end := i + len(term)
+			for end < len(lower) && isWordRune(lower[end]) {
+				end++
+			}
+			matches = append(matches, span{i, end})
+			i = end - 1
+			break
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start := matches[0].start - radius
+	if start < 0 {
+		start = 0
+	}
+	end := matches[0].end + radius
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	pos := start
+	for _, match := range matches {
+		if match.start < start || match.end > end {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(string(runes[pos:match.start])))
+		snippet.WriteString("<mark>")
+		snippet.WriteString(html.EscapeString(string(runes[match.start:match.end])))
+		snippet.WriteString("</mark>")
+		pos = match.end
+	}
+	snippet.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -9,35 +9,29 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-// Custom validation tags
-const (
-	StatusEnum = "status_enum"
-)
-
 // Use a single instance of Validator, it caches struct info
 var validate *validator.Validate
 
-// InitValidator initializes the validator with custom validations
+// InitValidator initializes the validator and configures Gin's binding validator
 func InitValidator() {
 	validate = validator.New()
-
-	// Register custom validation tags
-	validate.RegisterValidation(StatusEnum, validateTaskStatus)
+	// Report fields by their JSON names, e.g. in per-row import errors
+	validate.RegisterTagNameFunc(jsonTagName)
 
 	// Get validator from Gin's validator engine
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		// Register custom tag name function
-		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-			name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-			if name == "-" {
-				return ""
-			}
-			return name
-		})
+		v.RegisterTagNameFunc(jsonTagName)
+	}
+}
 
-		// Register custom validations
-		v.RegisterValidation(StatusEnum, validateTaskStatus)
+// jsonTagName names a struct field after its JSON key, falling back to the Go field name
+func jsonTagName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
 	}
+	return name
 }
 
 // ValidateStruct validates a struct using tags
@@ -56,18 +50,6 @@ func ValidateStruct(s interface{}) error {
 	return nil
 }
 
-// Custom validation function for task status
-func validateTaskStatus(fl validator.FieldLevel) bool {
-	status := fl.Field().String()
-	validStatuses := []string{"pending", "in_progress", "completed"}
-	for _, s := range validStatuses {
-		if status == s {
-			return true
-		}
-	}
-	return false
-}
-
 // formatValidationError formats validation errors in a user-friendly way
 func formatValidationError(err validator.FieldError) string {
 	field := err.Field()
@@ -80,8 +62,10 @@ func formatValidationError(err validator.FieldError) string {
 		return fmt.Sprintf("%s must be at most %s", field, err.Param())
 	case "email":
 		return fmt.Sprintf("%s must be a valid email address", field)
-	case StatusEnum:
-		return fmt.Sprintf("%s must be one of: pending, in_progress, completed", field)
+	case "mongodb":
+		return fmt.Sprintf("%s must be a valid ID", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(err.Param(), " ", ", "))
 	default:
 		return fmt.Sprintf("%s failed %s validation", field, err.Tag())
 	}